@@ -21,13 +21,36 @@ export type Translation = {
 export type TParams = {
 	code: string;
 	desc?: string;
+	// Disambiguates equal messages that need different translations, only used by the string extractor
+	context?: string;
 } & Translation;
 
 export type TranslationGetter = (params: TParams) => Translation;
 
-export type Translator = (
-	params: TParams,
-	replacements?: Record<string, any> | undefined | null,
+// Maps every code to the type of its replacements. Empty unless the declarations generated by the string extractor with --types-file are part of the build, then unknown codes and missing replacements fail type-checking.
+export interface TranslationReplacements {}
+
+export type TranslationCode = keyof TranslationReplacements & string;
+
+type AnyReplacements = Record<string, any>;
+type Replacements = AnyReplacements | undefined | null;
+
+// The replacements argument for code C. Codes that are not literals, and all codes without the generated declarations, are not checked. An unknown code makes the replacements never, so the call does not type-check. Plural messages whose number can have any key are declared with an index signature, their replacements are required too.
+type TranslatorReplacements<C extends string> = string extends C
+	? [replacements?: Replacements]
+	: [TranslationCode] extends [never]
+		? [replacements?: Replacements]
+		: C extends TranslationCode
+			? {} extends TranslationReplacements[C]
+				? string extends keyof TranslationReplacements[C]
+					? [replacements: TranslationReplacements[C] & AnyReplacements]
+					: [replacements?: (TranslationReplacements[C] & AnyReplacements) | null]
+				: [replacements: TranslationReplacements[C] & AnyReplacements]
+			: [replacements: never];
+
+export type Translator = <C extends string>(
+	params: TParams & { code: C },
+	...replacements: TranslatorReplacements<C>
 ) => string;
 
 export function createTranslator(
@@ -35,7 +58,7 @@ export function createTranslator(
 	lang: string,
 	debug: boolean,
 ): Translator {
-	return function (params, replacements) {
+	return function (params: TParams, replacements?: Replacements): string {
 		let strOrArr: string | string[];
 
 		// Get translated structure: { msg } or { msgs }
@@ -92,7 +115,7 @@ export function createTranslator(
 		}
 
 		return str;
-	};
+	} as Translator;
 }
 
 function normalizeString(strOrArr: string | string[]): string {
@@ -139,5 +162,5 @@ export function createMockTranslator(): Translator {
 		}
 
 		return str;
-	};
+	} as Translator;
 }
@@ -8,18 +8,52 @@ import (
 
 // CacheMem is a simple in-memory store: from -> to -> text -> translation
 type CacheMem struct {
-	// data[from][to][text] = translation
-	data map[string]map[string]map[string]string
+	// data[from][to][cacheKey(text, context)] = translation
+	data map[string]map[string]map[string]cacheEntry
+}
+
+// cacheEntry is a translation and the provider that produced it. Translations of the defaultProvider are stored as plain strings, as all entries were before there were other providers, so the cache file does not change for them.
+type cacheEntry struct {
+	Text     string `json:"text"`
+	Provider string `json:"provider"`
+}
+
+func (e cacheEntry) MarshalJSON() ([]byte, error) {
+	if e.Provider == defaultProvider {
+		return json.Marshal(e.Text)
+	}
+	type entry cacheEntry
+	return json.Marshal(entry(e))
+}
+
+func (e *cacheEntry) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		e.Provider = defaultProvider
+		return json.Unmarshal(data, &e.Text)
+	}
+	type entry cacheEntry
+	return json.Unmarshal(data, (*entry)(e))
+}
+
+// contextSeparator separates the text from its context in cache keys, same as msgctxt in gettext mo files.
+const contextSeparator = "\x04"
+
+// cacheKey is the text for texts without context, so existing cache entries stay valid.
+func cacheKey(text, context string) string {
+	if context == "" {
+		return text
+	}
+	return context + contextSeparator + text
 }
 
 // NewCacheMem creates a new in-memory cache.
 func NewCacheMem() *CacheMem {
 	return &CacheMem{
-		data: make(map[string]map[string]map[string]string),
+		data: make(map[string]map[string]map[string]cacheEntry),
 	}
 }
 
-// Get looks up a translation.
+// Get looks up a translation, text is a cacheKey.
 func (c *CacheMem) Get(text, from, to string) (string, bool) {
 	if _, ok := c.data[from]; !ok {
 		return "", false
@@ -27,19 +61,19 @@ func (c *CacheMem) Get(text, from, to string) (string, bool) {
 	if _, ok := c.data[from][to]; !ok {
 		return "", false
 	}
-	trans, ok := c.data[from][to][text]
-	return trans, ok
+	entry, ok := c.data[from][to][text]
+	return entry.Text, ok
 }
 
-// Set stores a translation.
-func (c *CacheMem) Set(text, from, to, trans string) {
+// Set stores a translation of provider, text is a cacheKey.
+func (c *CacheMem) Set(text, from, to, trans, provider string) {
 	if _, ok := c.data[from]; !ok {
-		c.data[from] = make(map[string]map[string]string)
+		c.data[from] = make(map[string]map[string]cacheEntry)
 	}
 	if _, ok := c.data[from][to]; !ok {
-		c.data[from][to] = make(map[string]string)
+		c.data[from][to] = make(map[string]cacheEntry)
 	}
-	c.data[from][to][text] = trans
+	c.data[from][to][text] = cacheEntry{Text: trans, Provider: provider}
 }
 
 // CacheFile handles persistence.
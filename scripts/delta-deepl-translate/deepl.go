@@ -8,133 +8,59 @@ import (
 	"strings"
 )
 
-// DeepLTranslator uses the new TranslationCache
-type DeepLTranslator struct {
+// DeepLProvider translates with the DeepL API.
+type DeepLProvider struct {
 	apiURL string
 	apiKey string
-	cache  *CacheFile
 }
 
-func NewDeepLTranslator(apiURL, apiKey, cachePath string) (*DeepLTranslator, error) {
-	cache, err := NewCacheFile(cachePath)
-	if err != nil {
-		return nil, err
+func newDeepLProvider(config providerConfig) Provider {
+	return &DeepLProvider{
+		apiURL: config.apiURL,
+		apiKey: config.apiKey,
 	}
-
-	return &DeepLTranslator{
-		apiURL: apiURL,
-		apiKey: apiKey,
-		cache:  cache,
-	}, nil
 }
 
 const maxCharsPerRequest = 50000
 
-func (t *DeepLTranslator) TranslateBatch(ctx context.Context, texts []string, targetLang, sourceLang string) ([]string, error) {
-	results := make([]string, len(texts))
-
-	// First: fill from cache
-	toTranslate := []string{}
-	toTranslateIndices := []int{}
-
-	for i, text := range texts {
-		if trans, ok := t.cache.Get(text, sourceLang, targetLang); ok {
-			results[i] = trans
-			continue
-		}
-		toTranslate = append(toTranslate, text)
-		toTranslateIndices = append(toTranslateIndices, i)
-	}
-
-	if len(toTranslate) == 0 {
-		return results, nil
-	}
-
-	// Process in batches by character count
-	batchTexts := []string{}
-	batchIndices := []int{}
-
-	for i, text := range toTranslate {
-		// Check batch size
-		currentChars := 0
-		for _, s := range batchTexts {
-			currentChars += len(s)
-		}
-		if currentChars+len(text) > maxCharsPerRequest && len(batchTexts) > 0 {
-			// Send current batch
-			if err := t.sendBatch(ctx, batchTexts, targetLang, sourceLang, &results, &toTranslateIndices, batchIndices); err != nil {
-				return nil, err
-			}
-			batchTexts = nil
-			batchIndices = nil
-		}
-		batchTexts = append(batchTexts, text)
-		batchIndices = append(batchIndices, i)
-	}
-
-	// Final batch
-	if len(batchTexts) > 0 {
-		if err := t.sendBatch(ctx, batchTexts, targetLang, sourceLang, &results, &toTranslateIndices, batchIndices); err != nil {
-			return nil, err
-		}
-	}
-
-	return results, nil
+func (p *DeepLProvider) Name() string {
+	return "deepl"
 }
 
-func (t *DeepLTranslator) sendBatch(
-	ctx context.Context,
-	texts []string,
-	targetLang, sourceLang string,
-	results *[]string,
-	toTranslateIndices *[]int,
-	batchLocalIndices []int,
-) error {
-	translated, err := t.callDeepL(ctx, texts, targetLang)
-	if err != nil {
-		return err
-	}
-
-	// Save each result to cache
-	for j, translation := range translated {
-		originalText := texts[j]
-		globalIdx := (*toTranslateIndices)[batchLocalIndices[j]]
-
-		t.cache.Set(originalText, sourceLang, targetLang, translation)
-		(*results)[globalIdx] = translation
-	}
-
-	// Persist entire cache after batch
-	if err := t.cache.Save(); err != nil {
-		return fmt.Errorf("failed to save cache: %w", err)
-	}
-
-	return nil
+// Limits are the request limits of the DeepL API, at most 50 texts per request.
+func (p *DeepLProvider) Limits() BatchLimits {
+	return BatchLimits{MaxChars: maxCharsPerRequest, MaxTexts: 50}
 }
 
-func (t *DeepLTranslator) callDeepL(ctx context.Context, texts []string, targetLang string) ([]string, error) {
+// Translate sends the context of the texts as the DeepL context parameter. It influences the translation but is not translated or billed.
+func (p *DeepLProvider) Translate(ctx context.Context, texts []SourceText, sourceLang, targetLang string) ([]string, error) {
 	type request struct {
 		Text       []string `json:"text"`
 		TargetLang string   `json:"target_lang"`
+		Context    string   `json:"context,omitempty"`
 	}
 
 	reqBody := request{
-		Text:       texts,
+		Text:       make([]string, len(texts)),
 		TargetLang: targetLang,
 	}
+	for i, text := range texts {
+		reqBody.Text[i] = text.Text
+		reqBody.Context = text.Context
+	}
 
 	data, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", t.apiURL+"/v2/translate", strings.NewReader(string(data)))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL+"/v2/translate", strings.NewReader(string(data)))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "DeepL-Auth-Key "+t.apiKey)
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+p.apiKey)
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
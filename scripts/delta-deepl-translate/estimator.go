@@ -23,24 +23,25 @@ func NewTranslationEstimator(cacheFile string) (*TranslationEstimator, error) {
 
 // Estimate checks if the text is already in persistent or in-progress cache.
 // If not, it adds to the estimated character count.
-func (e *TranslationEstimator) Estimate(text, sourceLang, targetLang string) {
-	if text == "" {
+func (e *TranslationEstimator) Estimate(text SourceText, sourceLang, targetLang string) {
+	if text.Text == "" {
 		return
 	}
+	key := cacheKey(text.Text, text.Context)
 
 	// Skip if already in persistent cache
-	if _, found := e.fileCache.Get(text, sourceLang, targetLang); found {
+	if _, found := e.fileCache.Get(key, sourceLang, targetLang); found {
 		return
 	}
 
 	// Skip if already tracked in this batch (dedup)
-	if _, found := e.memCache.Get(text, sourceLang, targetLang); found {
+	if _, found := e.memCache.Get(key, sourceLang, targetLang); found {
 		return
 	}
 
-	// New text: count it and track in memCache
-	e.charCount += len(text)
-	e.memCache.Set(text, sourceLang, targetLang, "_placeholder_") // value doesn't matter
+	// New text: count it and track in memCache. DeepL does not bill the context.
+	e.charCount += len(text.Text)
+	e.memCache.Set(key, sourceLang, targetLang, "_placeholder_", "") // value doesn't matter
 }
 
 // Total returns the estimated total characters to be sent.
@@ -8,6 +8,7 @@ import (
 type TranslationEntry struct {
 	ID          string `json:"id"`
 	Description string `json:"description,omitempty"`
+	Context     string `json:"context,omitempty"`
 	Translation any    `json:"translation"`
 }
 
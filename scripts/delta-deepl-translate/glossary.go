@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// Glossary are translations of terms by target language, from a JSON file such as
+//
+//	{"fr": {"hazard": "aléa", "sector": "secteur"}}
+type Glossary map[string]map[string]string
+
+func ReadGlossary(filename string) (Glossary, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var glossary Glossary
+	err = json.Unmarshal(data, &glossary)
+	if err != nil {
+		return nil, err
+	}
+
+	return glossary, nil
+}
+
+// Terms returns the terms of targetLang that occur in texts, ignoring case, so prompts only list the terms they need.
+func (g Glossary) Terms(targetLang string, texts []SourceText) map[string]string {
+	terms := make(map[string]string)
+	for term, translation := range g[targetLang] {
+		lower := strings.ToLower(term)
+		for _, text := range texts {
+			if strings.Contains(strings.ToLower(text.Text), lower) {
+				terms[term] = translation
+				break
+			}
+		}
+	}
+	return terms
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// LibreTranslateProvider translates with a LibreTranslate server, usually a self-hosted one so texts do not leave the network. LibreTranslate has no context parameter, texts with a context are translated the same as without.
+type LibreTranslateProvider struct {
+	apiURL string
+	apiKey string
+	// sources are the codes of the server for the source languages, see languages
+	sources map[string]string
+	// targets are the target languages by source language, see Languages
+	targets map[string][]string
+}
+
+func newLibreTranslateProvider(config providerConfig) Provider {
+	return &LibreTranslateProvider{
+		apiURL:  strings.TrimSuffix(config.apiURL, "/"),
+		apiKey:  config.apiKey,
+		sources: make(map[string]string),
+		targets: make(map[string][]string),
+	}
+}
+
+func (p *LibreTranslateProvider) Name() string {
+	return "libretranslate"
+}
+
+// Limits stay below the limits of public instances, the limits of a self-hosted server are set with its --char-limit and --batch-limit options.
+func (p *LibreTranslateProvider) Limits() BatchLimits {
+	return BatchLimits{MaxChars: 5000, MaxTexts: 25}
+}
+
+// Languages returns the languages the server translates sourceLang to, from its /languages endpoint.
+func (p *LibreTranslateProvider) Languages(ctx context.Context, sourceLang string) ([]string, error) {
+	_, targets, err := p.languages(ctx, sourceLang)
+	return targets, err
+}
+
+// languages returns the code of the server for sourceLang, for example en for EN or zh-Hans for zh, and the languages it translates it to.
+func (p *LibreTranslateProvider) languages(ctx context.Context, sourceLang string) (string, []string, error) {
+	if targets, ok := p.targets[sourceLang]; ok {
+		return p.sources[sourceLang], targets, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", p.apiURL+"/languages", nil)
+	if err != nil {
+		return "", nil, err
+	}
+	var languages []struct {
+		Code    string   `json:"code"`
+		Targets []string `json:"targets"`
+	}
+	if err := p.do(req, &languages); err != nil {
+		return "", nil, err
+	}
+	codes := make([]string, 0, len(languages))
+	for _, l := range languages {
+		codes = append(codes, l.Code)
+	}
+	source, ok := matchLanguage(codes, sourceLang)
+	if !ok {
+		return "", nil, fmt.Errorf("libretranslate does not translate from %s, it supports %s", sourceLang, strings.Join(codes, ", "))
+	}
+	var targets []string
+	for _, l := range languages {
+		if l.Code != source {
+			continue
+		}
+		targets = l.Targets
+		// servers before targets were listed translate between all languages
+		if len(targets) == 0 {
+			for _, code := range codes {
+				if code != source {
+					targets = append(targets, code)
+				}
+			}
+		}
+	}
+	p.sources[sourceLang] = source
+	p.targets[sourceLang] = targets
+	return source, targets, nil
+}
+
+func (p *LibreTranslateProvider) Translate(ctx context.Context, texts []SourceText, sourceLang, targetLang string) ([]string, error) {
+	source, targets, err := p.languages(ctx, sourceLang)
+	if err != nil {
+		return nil, err
+	}
+	target, ok := matchLanguage(targets, targetLang)
+	if !ok {
+		return nil, fmt.Errorf("libretranslate does not translate from %s to %s", sourceLang, targetLang)
+	}
+
+	type request struct {
+		Q      []string `json:"q"`
+		Source string   `json:"source"`
+		Target string   `json:"target"`
+		Format string   `json:"format"`
+		APIKey string   `json:"api_key,omitempty"`
+	}
+	reqBody := request{
+		Q:      make([]string, len(texts)),
+		Source: source,
+		Target: target,
+		Format: "text",
+		APIKey: p.apiKey,
+	}
+	for i, text := range texts {
+		reqBody.Q[i] = text.Text
+	}
+
+	data, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL+"/translate", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	if err := p.do(req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.TranslatedText, nil
+}
+
+// do sends a request and decodes the JSON response into out. Errors include the error message of the server.
+func (p *LibreTranslateProvider) do(req *http.Request, out any) error {
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Error != "" {
+			return fmt.Errorf("libretranslate error: status %d: %s", resp.StatusCode, errResp.Error)
+		}
+		return fmt.Errorf("libretranslate error: status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
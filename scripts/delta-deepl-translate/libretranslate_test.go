@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLibreTranslateProvider(t *testing.T) {
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/languages":
+			w.Write([]byte(`[
+				{"code": "en", "name": "English", "targets": ["en", "fr", "sr", "zh-Hans"]},
+				{"code": "fr", "name": "French", "targets": ["en", "fr"]}
+			]`))
+		case "/translate":
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			requests = append(requests, body)
+			if body["api_key"] != "secret" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error": "Invalid API key"}`))
+				return
+			}
+			var res []string
+			for _, q := range body["q"].([]any) {
+				res = append(res, body["target"].(string)+":"+q.(string))
+			}
+			json.NewEncoder(w).Encode(map[string]any{"translatedText": res})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	p := newLibreTranslateProvider(providerConfig{apiURL: server.URL + "/", apiKey: "secret"})
+	if err := checkLanguages(ctx, p, "en", []string{"sr", "zh"}); err != nil {
+		t.Errorf("wanted sr and zh to be supported, got %v", err)
+	}
+	err := checkLanguages(ctx, p, "en", []string{"fr", "ar", "ru"})
+	if err == nil || !strings.Contains(err.Error(), "to ar, ru") {
+		t.Errorf("wanted ar and ru to be unsupported, got %v", err)
+	}
+
+	got, err := p.Translate(ctx, []SourceText{{Text: "Save {0}"}, {Text: "Close"}}, "en", "zh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "zh-Hans:Save {0},zh-Hans:Close" {
+		t.Errorf("wanted both texts translated to zh-Hans in one request, got %v", got)
+	}
+	if len(requests) != 1 || requests[0]["format"] != "text" || requests[0]["source"] != "en" {
+		t.Errorf("wanted one text request from en, got %v", requests)
+	}
+
+	p = newLibreTranslateProvider(providerConfig{apiURL: server.URL})
+	_, err = p.Translate(ctx, []SourceText{{Text: "Save"}}, "en", "fr")
+	if err == nil || !strings.Contains(err.Error(), "Invalid API key") {
+		t.Errorf("wanted the error of the server, got %v", err)
+	}
+	if _, ok := requests[1]["api_key"]; ok {
+		t.Errorf("wanted no api_key without a key, got %v", requests[1])
+	}
+
+	// the source language is sent with the code of the server, as the target
+	p = newLibreTranslateProvider(providerConfig{apiURL: server.URL, apiKey: "secret"})
+	if _, err := p.Translate(ctx, []SourceText{{Text: "Save"}}, "EN", "FR"); err != nil {
+		t.Fatal(err)
+	}
+	if last := requests[len(requests)-1]; last["source"] != "en" || last["target"] != "fr" {
+		t.Errorf("wanted a request from en to fr, got %v", last)
+	}
+}
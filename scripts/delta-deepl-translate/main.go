@@ -43,6 +43,9 @@ func exec() error {
 		return err
 	}
 
+	ctx := context.Background()
+	// provider is created for the first subdir that is translated, not for dry runs
+	var provider Provider
 	for _, subDir := range args.SubDirs {
 		sourceFile := filepath.Join(args.Dir, subDir, args.SourceLang+".json")
 
@@ -61,7 +64,7 @@ func exec() error {
 		sourceTexts := extractTexts(entries)
 
 		// Show cost estimate
-		if err := estimateCost(sourceTexts, args.Langs, args.SourceLang, args.CacheFile); err != nil {
+		if err := estimateCost(sourceTexts, args.Langs, args.SourceLang, args.CacheFile, args.Provider.pricePerMillion); err != nil {
 			return err
 		}
 
@@ -71,12 +74,19 @@ func exec() error {
 		}
 
 		// Initialize translator and translate
-		translator, err := NewDeepLTranslator(args.APIURL, args.APIKey, args.CacheFile)
+		if provider == nil {
+			if provider, err = newProvider(args); err != nil {
+				return err
+			}
+			if err := checkLanguages(ctx, provider, args.SourceLang, args.Langs); err != nil {
+				return err
+			}
+		}
+		translator, err := NewTranslator(provider, args.CacheFile)
 		if err != nil {
 			return fmt.Errorf("failed to initialize translator: %w", err)
 		}
 
-		ctx := context.Background()
 		for _, lang := range args.Langs {
 			if lang == args.SourceLang {
 				continue
@@ -98,7 +108,7 @@ func exec() error {
 				return fmt.Errorf("failed to write translation file %s: %w", targetFile, err)
 			}
 
-			fmt.Printf("Translated %d entries to %s and wrote to %s\n", len(entries), lang, targetFile)
+			fmt.Printf("Translated %d entries to %s with %s and wrote to %s\n", len(entries), lang, provider.Name(), targetFile)
 		}
 	}
 
@@ -106,24 +116,30 @@ func exec() error {
 }
 
 type args struct {
-	Dir        string
-	SourceLang string
-	APIKey     string
-	APIURL     string
-	Langs      CommaSeparated
-	CacheFile  string
-	DryRun     bool
-	Sample     bool
-	SubDirs    CommaSeparated
+	Dir          string
+	SourceLang   string
+	Provider     providerInfo
+	APIKeyEnvVar string
+	APIURL       string
+	Model        string
+	GlossaryFile string
+	Langs        CommaSeparated
+	CacheFile    string
+	DryRun       bool
+	Sample       bool
+	SubDirs      CommaSeparated
 }
 
 func parseFlags() (*args, error) {
 	dir := flag.String("dir", filepath.FromSlash("../../app/locales"), "Directory with json files with translations")
 	sourceLang := flag.String("source-lang", "en", "Source language for translations")
-	apiKeyEnvVar := flag.String("api-key-env-var", "DELTA_DEEPL_KEY", "Env var to read the API key from")
+	providerName := flag.String("provider", defaultProvider, "Translation service to use: "+providerNames())
+	apiKeyEnvVar := flag.String("api-key-env-var", "", "Env var to read the API key from (default DELTA_DEEPL_KEY for deepl, DELTA_LIBRETRANSLATE_KEY for libretranslate, DELTA_OPENAI_KEY for openai)")
 	dryRun := flag.Bool("dry-run", false, "If true, only count characters to translate, no API calls")
 	sample := flag.Bool("sample", false, "If true, only translates a small sample")
-	apiURL := flag.String("api-url", "https://api-free.deepl.com", "Which deepl url to use for translation")
+	apiURL := flag.String("api-url", "", "URL of the translation service (default https://api-free.deepl.com for deepl, http://localhost:5000 for libretranslate, http://localhost:8080/v1 for openai)")
+	model := flag.String("model", "", "Model to translate with, for openai")
+	glossaryFile := flag.String("glossary", "", "JSON file with translations of terms by language, for openai")
 
 	var langs CommaSeparated
 	flag.Var(&langs, "langs", "Comma-separated list of languages (e.g. fr,de,es)")
@@ -136,9 +152,21 @@ func parseFlags() (*args, error) {
 		subDirs = []string{"app", "content"}
 	}
 
-	apiKey := os.Getenv(*apiKeyEnvVar)
-	if apiKey == "" {
-		return nil, fmt.Errorf("API key environment variable %s is not set", *apiKeyEnvVar)
+	provider, err := lookupProvider(*providerName)
+	if err != nil {
+		return nil, err
+	}
+	if *apiKeyEnvVar == "" {
+		*apiKeyEnvVar = provider.apiKeyEnvVar
+	}
+	if *apiURL == "" {
+		*apiURL = provider.apiURL
+	}
+	if *apiURL == "" {
+		return nil, fmt.Errorf("provide --api-url for %s", *providerName)
+	}
+	if !provider.llm && (*model != "" || *glossaryFile != "") {
+		return nil, fmt.Errorf("--model and --glossary are not used by %s", *providerName)
 	}
 
 	cacheDir := filepath.Join(*dir, "api-cache")
@@ -148,18 +176,45 @@ func parseFlags() (*args, error) {
 	cacheFile := filepath.Join(cacheDir, "data.json")
 
 	return &args{
-		Dir:        *dir,
-		SourceLang: *sourceLang,
-		APIKey:     apiKey,
-		APIURL:     *apiURL,
-		Langs:      langs,
-		CacheFile:  cacheFile,
-		DryRun:     *dryRun,
-		Sample:     *sample,
-		SubDirs:    subDirs,
+		Dir:          *dir,
+		SourceLang:   *sourceLang,
+		Provider:     provider,
+		APIKeyEnvVar: *apiKeyEnvVar,
+		APIURL:       *apiURL,
+		Model:        *model,
+		GlossaryFile: *glossaryFile,
+		Langs:        langs,
+		CacheFile:    cacheFile,
+		DryRun:       *dryRun,
+		Sample:       *sample,
+		SubDirs:      subDirs,
 	}, nil
 }
 
+// newProvider creates the provider of --provider with the API key from the environment and the glossary of --glossary.
+func newProvider(args *args) (Provider, error) {
+	var apiKey string
+	if args.APIKeyEnvVar != "" {
+		apiKey = os.Getenv(args.APIKeyEnvVar)
+	}
+	if apiKey == "" && args.Provider.requiresKey {
+		return nil, fmt.Errorf("API key environment variable %s is not set", args.APIKeyEnvVar)
+	}
+	config := providerConfig{
+		apiURL: args.APIURL,
+		apiKey: apiKey,
+		model:  args.Model,
+	}
+	if args.GlossaryFile != "" {
+		glossary, err := ReadGlossary(args.GlossaryFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read glossary: %w", err)
+		}
+		config.glossary = glossary
+	}
+	return args.Provider.new(config), nil
+}
+
 func validateArgs(args *args) error {
 	if len(args.Langs) == 0 {
 		return fmt.Errorf("provide --langs flag")
@@ -172,13 +227,23 @@ func validateArgs(args *args) error {
 	return nil
 }
 
-func extractTexts(entries []TranslationEntry) []string {
-	var texts []string
+// SourceText is a text to translate with the context of its entry. Equal texts with different contexts are translated separately.
+type SourceText struct {
+	Text    string
+	Context string
+	// Description is the description of the entry, it is not part of the cache key
+	Description string
+	// Placeholders are the original placeholders of the neutralized {0}, {1}, ... in Text
+	Placeholders []string
+}
+
+func extractTexts(entries []TranslationEntry) []SourceText {
+	var texts []SourceText
 	for _, e := range entries {
 		switch v := e.Translation.(type) {
 		case string:
-			neutral, _ := neutralizePlaceholders(v)
-			texts = append(texts, neutral)
+			neutral, placeholders := neutralizePlaceholders(v)
+			texts = append(texts, SourceText{Text: neutral, Context: e.Context, Description: e.Description, Placeholders: placeholders})
 		case map[string]any:
 			keys := make([]string, 0, len(v))
 			for k := range v {
@@ -187,15 +252,15 @@ func extractTexts(entries []TranslationEntry) []string {
 			sort.Strings(keys)
 			for _, k := range keys {
 				if str, ok := v[k].(string); ok {
-					neutral, _ := neutralizePlaceholders(str)
-					texts = append(texts, neutral)
+					neutral, placeholders := neutralizePlaceholders(str)
+					texts = append(texts, SourceText{Text: neutral, Context: e.Context, Description: e.Description, Placeholders: placeholders})
 				}
 			}
 		}
 	}
 	return texts
 }
-func estimateCost(texts []string, langs CommaSeparated, sourceLang, cacheFile string) error {
+func estimateCost(texts []SourceText, langs CommaSeparated, sourceLang, cacheFile string, pricePerMillion float64) error {
 	estimator, err := NewTranslationEstimator(cacheFile)
 	if err != nil {
 		return fmt.Errorf("failed to initialize estimator: %w", err)
@@ -210,12 +275,13 @@ func estimateCost(texts []string, langs CommaSeparated, sourceLang, cacheFile st
 		}
 	}
 
-	const pricePerMillion = 20.00
 	totalChars := estimator.Total()
-	estimatedCost := (float64(totalChars) / 1_000_000) * pricePerMillion
-
 	fmt.Printf("Total estimated characters to translate: %d\n", totalChars)
-	fmt.Printf("Estimated cost (€20.00 per 1M characters): €%.4f\n", estimatedCost)
+	if pricePerMillion == 0 {
+		return nil
+	}
+	estimatedCost := (float64(totalChars) / 1_000_000) * pricePerMillion
+	fmt.Printf("Estimated cost (€%.2f per 1M characters): €%.4f\n", pricePerMillion, estimatedCost)
 	return nil
 }
 
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// OpenAIProvider translates with a language model behind an OpenAI-compatible chat completions API, such as a local llama.cpp or vLLM server. Prompts include the descriptions of the entries, what their placeholders stand for and the glossary terms of the texts. The model has to answer with JSON of a schema and answers are validated before they are returned, so only valid translations are cached.
+type OpenAIProvider struct {
+	apiURL   string
+	apiKey   string
+	model    string
+	glossary Glossary
+}
+
+func newOpenAIProvider(config providerConfig) Provider {
+	return &OpenAIProvider{
+		apiURL:   strings.TrimSuffix(config.apiURL, "/"),
+		apiKey:   config.apiKey,
+		model:    config.model,
+		glossary: config.glossary,
+	}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+// Limits keep prompts and answers well within the context of small local models.
+func (p *OpenAIProvider) Limits() BatchLimits {
+	return BatchLimits{MaxChars: 4000, MaxTexts: 20}
+}
+
+// maxAttempts is how often a batch is sent before an invalid answer fails the translation. Models are not deterministic, an answer that missed a placeholder is usually right when asked again.
+const maxAttempts = 3
+
+// errInvalidAnswer wraps the errors of answers that are retried.
+var errInvalidAnswer = errors.New("invalid answer")
+
+func (p *OpenAIProvider) Translate(ctx context.Context, texts []SourceText, sourceLang, targetLang string) ([]string, error) {
+	messages, err := p.prompt(texts, sourceLang, targetLang)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 1; ; attempt++ {
+		translations, err := p.complete(ctx, messages, len(texts))
+		if err == nil {
+			err = validateTranslations(texts, translations)
+		}
+		if err == nil {
+			return translations, nil
+		}
+		if !errors.Is(err, errInvalidAnswer) {
+			return nil, err
+		}
+		if attempt == maxAttempts {
+			return nil, fmt.Errorf("no valid answer in %d attempts: %w", maxAttempts, err)
+		}
+	}
+}
+
+// languageNames name languages in prompts, models follow names better than codes.
+var languageNames = map[string]string{
+	"ar": "Arabic",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"ru": "Russian",
+	"sr": "Serbian in Cyrillic script",
+	"zh": "Simplified Chinese",
+}
+
+func languageName(lang string) string {
+	if name, ok := languageNames[lang]; ok {
+		return name + " (" + lang + ")"
+	}
+	return lang
+}
+
+const systemPrompt = `You translate the user interface of DELTA Resilience, a system to track disaster losses and damages, from %s to %s.
+
+The user message is JSON with the texts to translate:
+- "description" and "context" explain where a text is shown, they are not translated.
+- Placeholders such as {0} are replaced with values when a text is shown. Keep every placeholder of a text exactly as it is, "placeholders" names what they stand for.
+- Translate the terms in "glossary" as it says.
+- Keep translations as short as the texts, they are labels, buttons and messages.
+
+Answer with JSON only, with one translation for every text: {"translations": [{"id": <id of the text>, "translation": "<translation>"}]}`
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// prompt returns the messages asking to translate texts.
+func (p *OpenAIProvider) prompt(texts []SourceText, sourceLang, targetLang string) ([]chatMessage, error) {
+	type promptText struct {
+		ID           int               `json:"id"`
+		Text         string            `json:"text"`
+		Context      string            `json:"context,omitempty"`
+		Description  string            `json:"description,omitempty"`
+		Placeholders map[string]string `json:"placeholders,omitempty"`
+	}
+	var user struct {
+		Glossary map[string]string `json:"glossary,omitempty"`
+		Texts    []promptText      `json:"texts"`
+	}
+	user.Glossary = p.glossary.Terms(targetLang, texts)
+	for i, text := range texts {
+		pt := promptText{
+			ID:          i,
+			Text:        text.Text,
+			Context:     text.Context,
+			Description: promptDescription(text.Description),
+		}
+		if len(text.Placeholders) != 0 {
+			pt.Placeholders = make(map[string]string, len(text.Placeholders))
+			for j, ph := range text.Placeholders {
+				pt.Placeholders[fmt.Sprintf("{%d}", j)] = ph
+			}
+		}
+		user.Texts = append(user.Texts, pt)
+	}
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+	return []chatMessage{
+		{Role: "system", Content: fmt.Sprintf(systemPrompt, languageName(sourceLang), languageName(targetLang))},
+		{Role: "user", Content: string(data)},
+	}, nil
+}
+
+// promptDescription removes the source locations the string extractor appends to descriptions, they do not help translating.
+func promptDescription(desc string) string {
+	if i := strings.LastIndex(desc, "File: "); i >= 0 {
+		desc = desc[:i]
+	}
+	return strings.TrimSpace(desc)
+}
+
+// translationsSchema is the JSON schema of answers with n translations.
+func translationsSchema(n int) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"translations": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"id":          map[string]any{"type": "integer"},
+						"translation": map[string]any{"type": "string"},
+					},
+					"required":             []string{"id", "translation"},
+					"additionalProperties": false,
+				},
+				"minItems": n,
+				"maxItems": n,
+			},
+		},
+		"required":             []string{"translations"},
+		"additionalProperties": false,
+	}
+}
+
+// complete sends the messages and returns the n translations of the answer by id.
+func (p *OpenAIProvider) complete(ctx context.Context, messages []chatMessage, n int) ([]string, error) {
+	reqBody := map[string]any{
+		"messages":    messages,
+		"temperature": 0,
+		"response_format": map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   "translations",
+				"strict": true,
+				"schema": translationsSchema(n),
+			},
+		},
+	}
+	if p.model != "" {
+		reqBody["model"] = p.model
+	}
+
+	data, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Error.Message != "" {
+			return nil, fmt.Errorf("openai error: status %d: %s", resp.StatusCode, errResp.Error.Message)
+		}
+		return nil, fmt.Errorf("openai error: status %d", resp.StatusCode)
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message      chatMessage `json:"message"`
+			FinishReason string      `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, err
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("%w: no choices", errInvalidAnswer)
+	}
+	choice := completion.Choices[0]
+	if choice.FinishReason == "length" {
+		return nil, fmt.Errorf("%w: the answer was cut off at the token limit", errInvalidAnswer)
+	}
+
+	var answer struct {
+		Translations []struct {
+			ID          *int    `json:"id"`
+			Translation *string `json:"translation"`
+		} `json:"translations"`
+	}
+	if err := json.Unmarshal([]byte(choice.Message.Content), &answer); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidAnswer, err)
+	}
+	if len(answer.Translations) != n {
+		return nil, fmt.Errorf("%w: %d translations for %d texts", errInvalidAnswer, len(answer.Translations), n)
+	}
+	translations := make([]string, n)
+	seen := make([]bool, n)
+	for _, t := range answer.Translations {
+		if t.ID == nil || t.Translation == nil {
+			return nil, fmt.Errorf("%w: translation without id or translation", errInvalidAnswer)
+		}
+		id := *t.ID
+		if id < 0 || id >= n || seen[id] {
+			return nil, fmt.Errorf("%w: unknown or repeated id %d", errInvalidAnswer, id)
+		}
+		seen[id] = true
+		translations[id] = *t.Translation
+	}
+	return translations, nil
+}
+
+// validateTranslations checks that translations are not empty and keep the placeholders of their texts.
+func validateTranslations(texts []SourceText, translations []string) error {
+	for i, text := range texts {
+		translation := translations[i]
+		if strings.TrimSpace(translation) == "" && strings.TrimSpace(text.Text) != "" {
+			return fmt.Errorf("%w: empty translation of %q", errInvalidAnswer, text.Text)
+		}
+		want := extractPlaceholders(text.Text)
+		got := extractPlaceholders(translation)
+		slices.Sort(want)
+		slices.Sort(got)
+		if !slices.Equal(want, got) {
+			return fmt.Errorf("%w: translation %q of %q has placeholders %v, wanted %v", errInvalidAnswer, translation, text.Text, got, want)
+		}
+	}
+	return nil
+}
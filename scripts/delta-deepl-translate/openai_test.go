@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIProvider(t *testing.T) {
+	// answers are returned in order, the first one lost a placeholder
+	answers := []string{
+		`{"translations": [{"id": 1, "translation": "Aléas"}, {"id": 0, "translation": "Aléa dans"}]}`,
+		`{"translations": [{"id": 1, "translation": "Aléas"}, {"id": 0, "translation": "Aléa dans {0}"}]}`,
+	}
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, body)
+		answer := answers[0]
+		if len(answers) > 1 {
+			answers = answers[1:]
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{
+				"message":       map[string]any{"role": "assistant", "content": answer},
+				"finish_reason": "stop",
+			}},
+		})
+	}))
+	defer server.Close()
+
+	p := newOpenAIProvider(providerConfig{
+		apiURL:   server.URL + "/v1/",
+		apiKey:   "secret",
+		model:    "local",
+		glossary: Glossary{"fr": {"hazard": "aléa", "sector": "secteur"}},
+	})
+	texts := []SourceText{
+		{Text: "Hazard in {0}", Description: "{country} is the country name. File: a.tsx:1", Placeholders: []string{"{country}"}},
+		{Text: "Hazards", Context: "menu"},
+	}
+	got, err := p.Translate(context.Background(), texts, "en", "fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "Aléa dans {0},Aléas" {
+		t.Errorf("wanted the translations by id, got %v", got)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("wanted the invalid answer to be retried, got %d requests", len(requests))
+	}
+
+	req := requests[0]
+	if req["model"] != "local" || req["response_format"].(map[string]any)["type"] != "json_schema" {
+		t.Errorf("wanted the model and a json schema, got %v", req)
+	}
+	messages := req["messages"].([]any)
+	system := messages[0].(map[string]any)["content"].(string)
+	if !strings.Contains(system, "from English (en) to French (fr)") {
+		t.Errorf("wanted the languages in the system prompt, got %s", system)
+	}
+	var user struct {
+		Glossary map[string]string
+		Texts    []struct {
+			ID           int
+			Text         string
+			Context      string
+			Description  string
+			Placeholders map[string]string
+		}
+	}
+	if err := json.Unmarshal([]byte(messages[1].(map[string]any)["content"].(string)), &user); err != nil {
+		t.Fatal(err)
+	}
+	if len(user.Glossary) != 1 || user.Glossary["hazard"] != "aléa" {
+		t.Errorf("wanted only the glossary terms of the texts, got %v", user.Glossary)
+	}
+	if len(user.Texts) != 2 || user.Texts[0].Description != "{country} is the country name." || user.Texts[0].Placeholders["{0}"] != "{country}" || user.Texts[1].Context != "menu" {
+		t.Errorf("wanted descriptions without locations, placeholders and contexts, got %+v", user.Texts)
+	}
+
+	answers = []string{`{"translations": [{"id": 0, "translation": "Aléa"}]}`}
+	requests = nil
+	_, err = p.Translate(context.Background(), texts, "en", "fr")
+	if err == nil || len(requests) != maxAttempts {
+		t.Errorf("wanted an error after %d invalid answers, got %v after %d", maxAttempts, err, len(requests))
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Provider is a machine translation service. Providers only send requests, caching, batching and placeholders are handled by Translator.
+type Provider interface {
+	// Name identifies the provider in --provider and in the cache entries of its translations
+	Name() string
+	// Limits returns the limits of a single request
+	Limits() BatchLimits
+	// Translate translates texts from sourceLang to targetLang in a single request and returns one translation per text, in order. All texts have the same context, providers that do not support contexts ignore it.
+	Translate(ctx context.Context, texts []SourceText, sourceLang, targetLang string) ([]string, error)
+}
+
+// BatchLimits limit the texts sent in one request, 0 is no limit.
+type BatchLimits struct {
+	MaxChars int
+	MaxTexts int
+}
+
+// languageLister is implemented by providers that can list the languages they translate to, --langs is checked against them before translating.
+type languageLister interface {
+	Languages(ctx context.Context, sourceLang string) ([]string, error)
+}
+
+// matchLanguage returns the code of lang in codes. A language without region or script, such as zh, also matches the first code with one, such as zh-Hans.
+func matchLanguage(codes []string, lang string) (string, bool) {
+	for _, code := range codes {
+		if strings.EqualFold(code, lang) {
+			return code, true
+		}
+	}
+	for _, code := range codes {
+		if base, _, ok := strings.Cut(code, "-"); ok && strings.EqualFold(base, lang) {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// checkLanguages reports target languages the provider does not translate to.
+func checkLanguages(ctx context.Context, provider Provider, sourceLang string, langs []string) error {
+	lister, ok := provider.(languageLister)
+	if !ok {
+		return nil
+	}
+	targets, err := lister.Languages(ctx, sourceLang)
+	if err != nil {
+		return fmt.Errorf("failed to list the languages of %s: %w", provider.Name(), err)
+	}
+	var unsupported []string
+	for _, lang := range langs {
+		if _, ok := matchLanguage(targets, lang); !ok {
+			unsupported = append(unsupported, lang)
+		}
+	}
+	if len(unsupported) != 0 {
+		return fmt.Errorf("%s does not translate from %s to %s, it supports %s", provider.Name(), sourceLang, strings.Join(unsupported, ", "), strings.Join(targets, ", "))
+	}
+	return nil
+}
+
+// providerConfig configures a provider, from the flags.
+type providerConfig struct {
+	apiURL string
+	apiKey string
+	// model is the model of --model, for llm providers
+	model string
+	// glossary is the glossary of --glossary, for llm providers
+	glossary Glossary
+}
+
+// providerInfo describes a provider for --provider.
+type providerInfo struct {
+	// apiURL is the default of --api-url
+	apiURL string
+	// apiKeyEnvVar is the default of --api-key-env-var
+	apiKeyEnvVar string
+	// requiresKey makes a missing API key an error, self-hosted services usually need none
+	requiresKey bool
+	// pricePerMillion is the price in euros of a million characters for the cost estimate, 0 if it is not billed by character
+	pricePerMillion float64
+	// llm providers are prompted, they use --model and --glossary
+	llm bool
+	new func(config providerConfig) Provider
+}
+
+// defaultProvider produced all translations of the cache before providers were added, see cacheEntry.
+const defaultProvider = "deepl"
+
+// providers are the providers --provider can select.
+var providers = map[string]providerInfo{
+	"deepl": {
+		apiURL:          "https://api-free.deepl.com",
+		apiKeyEnvVar:    "DELTA_DEEPL_KEY",
+		requiresKey:     true,
+		pricePerMillion: 20,
+		new:             newDeepLProvider,
+	},
+	"libretranslate": {
+		apiURL:       "http://localhost:5000",
+		apiKeyEnvVar: "DELTA_LIBRETRANSLATE_KEY",
+		new:          newLibreTranslateProvider,
+	},
+	"openai": {
+		apiURL:       "http://localhost:8080/v1",
+		apiKeyEnvVar: "DELTA_OPENAI_KEY",
+		llm:          true,
+		new:          newOpenAIProvider,
+	},
+}
+
+func providerNames() string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func lookupProvider(name string) (providerInfo, error) {
+	info, ok := providers[name]
+	if !ok {
+		return providerInfo{}, fmt.Errorf("unknown provider %q, use one of %s", name, providerNames())
+	}
+	return info, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Translator translates texts with a Provider. Cached translations are reused, whichever provider produced them, and the others are sent in batches within the limits of the provider.
+type Translator struct {
+	provider Provider
+	cache    *CacheFile
+}
+
+func NewTranslator(provider Provider, cachePath string) (*Translator, error) {
+	cache, err := NewCacheFile(cachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Translator{
+		provider: provider,
+		cache:    cache,
+	}, nil
+}
+
+func (t *Translator) TranslateBatch(ctx context.Context, texts []SourceText, targetLang, sourceLang string) ([]string, error) {
+	results := make([]string, len(texts))
+
+	// First: fill from cache
+	toTranslate := []SourceText{}
+	toTranslateIndices := []int{}
+
+	for i, text := range texts {
+		if trans, ok := t.cache.Get(cacheKey(text.Text, text.Context), sourceLang, targetLang); ok {
+			results[i] = trans
+			continue
+		}
+		toTranslate = append(toTranslate, text)
+		toTranslateIndices = append(toTranslateIndices, i)
+	}
+
+	if len(toTranslate) == 0 {
+		return results, nil
+	}
+
+	// Providers such as DeepL apply the context to all texts of a request, so texts are grouped by context
+	var contexts []string
+	byContext := make(map[string][]int)
+	for i, text := range toTranslate {
+		if _, ok := byContext[text.Context]; !ok {
+			contexts = append(contexts, text.Context)
+		}
+		byContext[text.Context] = append(byContext[text.Context], i)
+	}
+
+	limits := t.provider.Limits()
+	send := func(batch []int) error {
+		batchTexts := make([]SourceText, len(batch))
+		for j, i := range batch {
+			batchTexts[j] = toTranslate[i]
+		}
+		translated, err := t.sendBatch(ctx, batchTexts, targetLang, sourceLang)
+		if err != nil {
+			return err
+		}
+		for j, i := range batch {
+			results[toTranslateIndices[i]] = translated[j]
+		}
+		return nil
+	}
+	for _, textContext := range contexts {
+		// Process in batches by character and text count
+		var batch []int
+		batchChars := 0
+
+		for _, i := range byContext[textContext] {
+			text := toTranslate[i]
+			full := limits.MaxChars > 0 && batchChars+len(text.Text) > limits.MaxChars ||
+				limits.MaxTexts > 0 && len(batch) >= limits.MaxTexts
+			if full && len(batch) > 0 {
+				if err := send(batch); err != nil {
+					return nil, err
+				}
+				batch = nil
+				batchChars = 0
+			}
+			batch = append(batch, i)
+			batchChars += len(text.Text)
+		}
+
+		// Final batch
+		if len(batch) > 0 {
+			if err := send(batch); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// sendBatch translates texts with the provider and caches the translations.
+func (t *Translator) sendBatch(ctx context.Context, texts []SourceText, targetLang, sourceLang string) ([]string, error) {
+	translated, err := t.provider.Translate(ctx, texts, sourceLang, targetLang)
+	if err != nil {
+		return nil, err
+	}
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d translations for %d texts", t.provider.Name(), len(translated), len(texts))
+	}
+
+	// Save each result to cache
+	for j, translation := range translated {
+		original := texts[j]
+		t.cache.Set(cacheKey(original.Text, original.Context), sourceLang, targetLang, translation, t.provider.Name())
+	}
+
+	// Persist entire cache after batch
+	if err := t.cache.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save cache: %w", err)
+	}
+
+	return translated, nil
+}
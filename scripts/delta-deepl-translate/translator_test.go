@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubProvider translates by upper-casing and records its requests.
+type stubProvider struct {
+	limits   BatchLimits
+	requests [][]SourceText
+}
+
+func (p *stubProvider) Name() string {
+	return "stub"
+}
+
+func (p *stubProvider) Limits() BatchLimits {
+	return p.limits
+}
+
+func (p *stubProvider) Translate(ctx context.Context, texts []SourceText, sourceLang, targetLang string) ([]string, error) {
+	p.requests = append(p.requests, texts)
+	res := make([]string, len(texts))
+	for i, text := range texts {
+		res[i] = strings.ToUpper(text.Text)
+	}
+	return res, nil
+}
+
+func TestTranslateBatch(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(cachePath, []byte(`{"en": {"fr": {"Save": "Enregistrer"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := &stubProvider{limits: BatchLimits{MaxTexts: 2}}
+	tr, err := NewTranslator(p, cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := []SourceText{
+		{Text: "Save"},
+		{Text: "Close", Context: "verb"},
+		{Text: "Open"},
+		{Text: "Close", Context: "adjective"},
+		{Text: "Delete"},
+		{Text: "Edit"},
+	}
+	got, err := tr.TranslateBatch(context.Background(), texts, "fr", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Enregistrer", "CLOSE", "OPEN", "CLOSE", "DELETE", "EDIT"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+	// texts without context in batches of 2, then one request per context
+	if len(p.requests) != 4 {
+		t.Errorf("wanted 4 requests, got %v", p.requests)
+	}
+	for _, r := range p.requests {
+		for _, text := range r[1:] {
+			if text.Context != r[0].Context {
+				t.Errorf("wanted one context per request, got %v", r)
+			}
+		}
+	}
+
+	cache, err := NewCacheFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := cache.data["en"]["fr"]["Save"]; e.Provider != defaultProvider {
+		t.Errorf("wanted entries without provider to be from %s, got %+v", defaultProvider, e)
+	}
+	if e := cache.data["en"]["fr"][cacheKey("Close", "verb")]; e != (cacheEntry{Text: "CLOSE", Provider: "stub"}) {
+		t.Errorf("wanted the translation and provider in the cache, got %+v", e)
+	}
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"Save": "Enregistrer"`)) || !bytes.Contains(data, []byte(`"provider": "stub"`)) {
+		t.Errorf("wanted translations of %s as strings and of other providers with provider, got\n%s", defaultProvider, data)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"delta-string-extractor/extractor"
+)
+
+// cachedFile is the extraction result of a single file.
+type cachedFile struct {
+	ModTime int64            `json:"modTime"`
+	Size    int64            `json:"size"`
+	Hash    string           `json:"hash"`
+	Result  extractor.Result `json:"result"`
+}
+
+// fileCache stores extraction results on disk, so unchanged files are not parsed again. Files are matched by path and mtime, if only the mtime changed the content hash is compared before parsing. The path includes the root directory, since results hold paths relative to it, see cacheKey. The whole cache is discarded when the fingerprint (extractor binary and config) changes.
+type fileCache struct {
+	mu          sync.Mutex
+	path        string
+	Fingerprint string                `json:"fingerprint"`
+	Files       map[string]cachedFile `json:"files"`
+	seen        map[string]bool
+	// roots are the absolute directories scanned in this run, see save
+	roots map[string]bool
+	dirty bool
+}
+
+func defaultCacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "delta-string-extractor", "cache.json")
+}
+
+// cacheKey identifies a file of a root directory in the cache.
+func cacheKey(absDir, relPath string) string {
+	return absDir + "::" + filepath.ToSlash(relPath)
+}
+
+// cacheKeyRoot returns the root directory of a cacheKey.
+func cacheKeyRoot(key string) string {
+	root, _, _ := strings.Cut(key, "::")
+	return root
+}
+
+// cacheFingerprint identifies everything besides the file content and path that affects extraction results.
+func cacheFingerprint(cfg extractor.Config) string {
+	h := sha256.New()
+	if exe, err := os.Executable(); err == nil {
+		if f, err := os.Open(exe); err == nil {
+			_, _ = io.Copy(h, f)
+			f.Close()
+		}
+	}
+	cfgJSON, _ := json.Marshal(cfg)
+	fmt.Fprintf(h, "\n%s", cfgJSON)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadFileCache reads the cache at path. A missing or unreadable cache is not an error, extraction starts from scratch. An empty path disables the cache.
+func loadFileCache(path, fingerprint string) *fileCache {
+	c := &fileCache{
+		path:        path,
+		Fingerprint: fingerprint,
+		Files:       make(map[string]cachedFile),
+		seen:        make(map[string]bool),
+		roots:       make(map[string]bool),
+	}
+	if path == "" {
+		return c
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var loaded fileCache
+	if err := json.Unmarshal(data, &loaded); err != nil || loaded.Fingerprint != fingerprint || loaded.Files == nil {
+		return c
+	}
+	c.Files = loaded.Files
+	return c
+}
+
+// scan records that the files of the root absDir are extracted in this run, so save drops its files that were not seen.
+func (c *fileCache) scan(absDir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roots[absDir] = true
+}
+
+// lookup returns the cached result if the file was not modified since it was cached.
+func (c *fileCache) lookup(path string, info os.FileInfo) (extractor.Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen[path] = true
+	f, ok := c.Files[path]
+	if !ok || f.ModTime != info.ModTime().UnixNano() || f.Size != info.Size() {
+		return extractor.Result{}, false
+	}
+	return f.Result, true
+}
+
+// lookupHash returns the cached result if the content is unchanged, only the mtime differs.
+func (c *fileCache) lookupHash(path string, info os.FileInfo, hash string) (extractor.Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, ok := c.Files[path]
+	if !ok || f.Hash != hash {
+		return extractor.Result{}, false
+	}
+	f.ModTime = info.ModTime().UnixNano()
+	f.Size = info.Size()
+	c.Files[path] = f
+	c.dirty = true
+	return f.Result, true
+}
+
+func (c *fileCache) store(path string, info os.FileInfo, hash string, result extractor.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Files[path] = cachedFile{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Hash:    hash,
+		Result:  result,
+	}
+	c.dirty = true
+}
+
+// save writes the cache if anything changed. Files of the roots scanned in this run that were not seen are dropped, files of other roots are kept, also the ones another run sharing the cache file saved since it was loaded.
+func (c *fileCache) save() error {
+	if c.path == "" {
+		return nil
+	}
+	for path := range c.Files {
+		if c.roots[cacheKeyRoot(path)] && !c.seen[path] {
+			delete(c.Files, path)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+	if saved := loadFileCache(c.path, c.Fingerprint); len(saved.Files) != 0 {
+		for path, f := range saved.Files {
+			if _, ok := c.Files[path]; !ok && !c.roots[cacheKeyRoot(path)] {
+				c.Files[path] = f
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	c.dirty = false
+	return writeAtomically(c.path, data)
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// fileEntry is an entry of the output file as read back from disk.
+type fileEntry struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Context     string `json:"context,omitempty"`
+	Translation any    `json:"translation"`
+}
+
+func parseFileEntries(data []byte) (map[string]fileEntry, error) {
+	res := make(map[string]fileEntry)
+	if len(data) == 0 {
+		return res, nil
+	}
+	var list []fileEntry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, e := range list {
+		res[e.ID] = e
+	}
+	return res, nil
+}
+
+// keyDiff is the difference for a single id between the committed and the extracted file.
+type keyDiff struct {
+	ID  string
+	Old *fileEntry
+	New *fileEntry
+}
+
+// diffFileEntries compares ids, descriptions and translations. Formatting and order of the files are not compared.
+func diffFileEntries(old, new map[string]fileEntry) []keyDiff {
+	ids := make(map[string]bool)
+	for id := range old {
+		ids[id] = true
+	}
+	for id := range new {
+		ids[id] = true
+	}
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	var res []keyDiff
+	for _, id := range sorted {
+		o, inOld := old[id]
+		n, inNew := new[id]
+		d := keyDiff{ID: id}
+		if inOld {
+			d.Old = &o
+		}
+		if inNew {
+			d.New = &n
+		}
+		if inOld && inNew && o.Description == n.Description && o.Context == n.Context && reflect.DeepEqual(o.Translation, n.Translation) {
+			continue
+		}
+		res = append(res, d)
+	}
+	return res
+}
+
+// printDiff writes the diff per key, using - for the old file, for example the committed one, and + for the new one.
+func printDiff(w io.Writer, oldName, newName string, diffs []keyDiff) {
+	fmt.Fprintf(w, "--- %s\n", oldName)
+	fmt.Fprintf(w, "+++ %s\n", newName)
+	for _, d := range diffs {
+		switch {
+		case d.Old == nil:
+			fmt.Fprintf(w, "@@ added %s\n", d.ID)
+			fmt.Fprintf(w, "+ translation: %s\n", translationString(d.New.Translation))
+			fmt.Fprintf(w, "+ description: %q\n", d.New.Description)
+		case d.New == nil:
+			fmt.Fprintf(w, "@@ removed %s\n", d.ID)
+			fmt.Fprintf(w, "- translation: %s\n", translationString(d.Old.Translation))
+			fmt.Fprintf(w, "- description: %q\n", d.Old.Description)
+		default:
+			fmt.Fprintf(w, "@@ changed %s\n", d.ID)
+			if !reflect.DeepEqual(d.Old.Translation, d.New.Translation) {
+				fmt.Fprintf(w, "- translation: %s\n", translationString(d.Old.Translation))
+				fmt.Fprintf(w, "+ translation: %s\n", translationString(d.New.Translation))
+			}
+			if d.Old.Context != d.New.Context {
+				fmt.Fprintf(w, "- context: %q\n", d.Old.Context)
+				fmt.Fprintf(w, "+ context: %q\n", d.New.Context)
+			}
+			if d.Old.Description != d.New.Description {
+				fmt.Fprintf(w, "- description: %q\n", d.Old.Description)
+				fmt.Fprintf(w, "+ description: %q\n", d.New.Description)
+			}
+		}
+	}
+}
+
+func translationString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// checkOutputFile compares the regenerated output file data with the one on disk byte for byte. It returns false if the file is out of date, the ids that differ are printed as a diff.
+func checkOutputFile(outputFile string, data []byte) (bool, error) {
+	existingData, err := os.ReadFile(outputFile)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(logw, "%s does not exist, run the extractor\n", outputFile)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(existingData, data) {
+		return true, nil
+	}
+
+	extracted, err := parseFileEntries(data)
+	if err != nil {
+		return false, err
+	}
+	committed, err := parseFileEntries(existingData)
+	if err != nil {
+		fmt.Fprintf(logw, "%s is out of date: it is not a valid output file (%v), re-run the extractor\n", outputFile, err)
+		return false, nil
+	}
+	diffs := diffFileEntries(committed, extracted)
+	if len(diffs) == 0 {
+		fmt.Fprintf(logw, "%s is out of date: the ids match but the file differs in formatting or order, re-run the extractor\n", outputFile)
+		return false, nil
+	}
+	printDiff(logw, outputFile+" (committed)", outputFile+" (extracted)", diffs)
+	fmt.Fprintf(logw, "%s is out of date: %d ids differ, re-run the extractor\n", outputFile, len(diffs))
+	return false, nil
+}
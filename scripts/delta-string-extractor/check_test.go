@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffFileEntries(t *testing.T) {
+	old := map[string]fileEntry{
+		"a": {ID: "a", Description: "File: a.tsx:1", Translation: "A"},
+		"b": {ID: "b", Description: "File: a.tsx:2", Translation: "B"},
+		"c": {ID: "c", Description: "File: a.tsx:3", Translation: "C"},
+	}
+	new := map[string]fileEntry{
+		"a": {ID: "a", Description: "File: a.tsx:1", Translation: "A"},
+		"b": {ID: "b", Description: "File: a.tsx:5", Translation: "B"},
+		"d": {ID: "d", Description: "File: a.tsx:4", Translation: map[string]any{"one": "D", "other": "Ds"}},
+	}
+
+	var got []string
+	for _, d := range diffFileEntries(old, new) {
+		kind := "changed"
+		if d.Old == nil {
+			kind = "added"
+		} else if d.New == nil {
+			kind = "removed"
+		}
+		got = append(got, kind+" "+d.ID)
+	}
+	want := []string{"changed b", "removed c", "added d"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestCheckOutputFile(t *testing.T) {
+	var log bytes.Buffer
+	defer func(w io.Writer) { logw = w }(logw)
+	logw = &log
+
+	data := "[\n  {\n    \"id\": \"a\",\n    \"description\": \"File: a.tsx:1\",\n    \"translation\": \"A\"\n  }\n]"
+	outputFile := filepath.Join(t.TempDir(), "en.json")
+	cases := []struct {
+		name, committed string
+		want            bool
+		log             string
+	}{
+		{"same", data, true, ""},
+		{"formatting", strings.ReplaceAll(data, "  ", "\t"), false, "differs in formatting or order"},
+		{"changed", strings.Replace(data, `"A"`, `"B"`, 1), false, "1 ids differ"},
+		{"invalid", "[", false, "not a valid output file"},
+		{"missing", "", false, "does not exist"},
+	}
+	for _, c := range cases {
+		log.Reset()
+		os.Remove(outputFile)
+		if c.committed != "" {
+			if err := os.WriteFile(outputFile, []byte(c.committed), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := checkOutputFile(outputFile, []byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want || !strings.Contains(log.String(), c.log) {
+			t.Errorf("%s: wanted %v and %q, got %v and %q", c.name, c.want, c.log, got, log.String())
+		}
+	}
+}
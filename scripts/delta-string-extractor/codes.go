@@ -0,0 +1,280 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"delta-string-extractor/extractor"
+)
+
+// codeGenerator derives codes for new messages. Codes already used for another message are never generated, a code already used for the same message is reused.
+type codeGenerator struct {
+	// messages maps existing codes to the messageID of their message
+	messages map[string]string
+}
+
+func newCodeGenerator() *codeGenerator {
+	return &codeGenerator{messages: make(map[string]string)}
+}
+
+func (g *codeGenerator) addEntries(entries []extractor.Entry) {
+	for _, e := range entries {
+		if _, ok := g.messages[e.Code]; !ok && e.Code != "" {
+			g.messages[e.Code] = entryMessageID(e)
+		}
+	}
+}
+
+// addOutputFile adds the codes of an output file such as en.json, including ones no source uses any more. A missing file is not an error.
+func (g *codeGenerator) addOutputFile(outputFile string) error {
+	data, err := os.ReadFile(outputFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entries, err := parseFileEntries(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", outputFile, err)
+	}
+	for id, e := range entries {
+		if _, ok := g.messages[id]; !ok {
+			g.messages[id] = messageID(e.Translation, e.Context)
+		}
+	}
+	return nil
+}
+
+// code returns namespace.key for the message with the given messageID, with a _2, _3... suffix if the code is used for another message. The code is reserved for the message.
+func (g *codeGenerator) code(namespace, key, id string) string {
+	base := key
+	if namespace != "" {
+		base = namespace + "." + key
+	}
+	code := base
+	for i := 2; ; i++ {
+		existing, ok := g.messages[code]
+		if !ok || existing == id {
+			break
+		}
+		code = fmt.Sprintf("%s_%d", base, i)
+	}
+	g.messages[code] = id
+	return code
+}
+
+// messageID identifies a message for reusing codes: the translation, a string or plural forms, as JSON followed by the context.
+func messageID(translation any, context string) string {
+	return translationString(translation) + "\x00" + context
+}
+
+func entryMessageID(e extractor.Entry) string {
+	if len(e.Msgs) != 0 {
+		return messageID(e.Msgs, e.Context)
+	}
+	return messageID(e.Msg, e.Context)
+}
+
+// Modes of --generate-codes.
+const (
+	// generateCodesHash generates codes from a hash of the message, they only change when the message does
+	generateCodesHash = "hash"
+	// generateCodesSlug generates readable codes from the first words of the message, see slugify
+	generateCodesSlug = "slug"
+)
+
+func validateGenerateCodes(mode string) error {
+	switch mode {
+	case "", generateCodesHash, generateCodesSlug:
+		return nil
+	}
+	return fmt.Errorf("invalid --generate-codes %q, use %s or %s", mode, generateCodesHash, generateCodesSlug)
+}
+
+// generateCodes sets the code of entries without one to the namespace of their file, see fileNamespace, followed by a hash or slug of the message. Codes of the output file are only reused for the same message, so the codes of unchanged messages stay the same between runs. It returns the entries it generated codes for.
+func generateCodes(entries []extractor.Entry, outputFile, mode string) ([]extractor.Entry, error) {
+	codes := newCodeGenerator()
+	codes.addEntries(entries)
+	if err := codes.addOutputFile(outputFile); err != nil {
+		return nil, err
+	}
+	namespaces := make(map[string]string)
+	var generated []extractor.Entry
+	for i := range entries {
+		e := &entries[i]
+		if e.Code != "" {
+			continue
+		}
+		file, _, _ := e.Position()
+		ns, ok := namespaces[file]
+		if !ok {
+			ns = fileNamespace(file, entries)
+			namespaces[file] = ns
+		}
+		id := entryMessageID(*e)
+		key := messageHash(id)
+		if mode == generateCodesSlug {
+			key = slugify(messageText(*e))
+		}
+		e.Code = codes.code(ns, key, id)
+		generated = append(generated, *e)
+	}
+	return generated, nil
+}
+
+// messageText returns msg, or for plural messages the other form or else the first one.
+func messageText(e extractor.Entry) string {
+	if len(e.Msgs) == 0 {
+		return e.Msg
+	}
+	if msg := e.Msgs["other"]; msg != "" {
+		return msg
+	}
+	forms := make([]string, 0, len(e.Msgs))
+	for form := range e.Msgs {
+		forms = append(forms, form)
+	}
+	sort.Strings(forms)
+	return e.Msgs[forms[0]]
+}
+
+// maxSlugWords limits the length of codes generated from long messages.
+const maxSlugWords = 5
+
+// slugify returns the first words of text as lower case snake_case, for example save_draft for "Save draft". {placeholders} are kept as words. Text without ASCII letters or digits falls back to messageHash.
+func slugify(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "text_" + messageHash(text)
+	}
+	if len(words) > maxSlugWords {
+		words = words[:maxSlugWords]
+	}
+	return strings.Join(words, "_")
+}
+
+// messageHash returns a short stable hash of a message, for codes that do not depend on the wording.
+func messageHash(msg string) string {
+	sum := sha256.Sum256([]byte(msg))
+	return hex.EncodeToString(sum[:4])
+}
+
+// fileNamespace returns the namespace most codes of the file use, or one derived from the path if it has none. For example routes/$lang+/settings+/api-key+/_index.tsx is api_key and components/TreeView.tsx is tree_view.
+func fileNamespace(relPath string, entries []extractor.Entry) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, e := range entries {
+		file, _, _ := e.Position()
+		ns, _, ok := strings.Cut(e.Code, ".")
+		if filepath.ToSlash(file) != filepath.ToSlash(relPath) || !ok {
+			continue
+		}
+		counts[ns]++
+		if counts[ns] > counts[best] || counts[ns] == counts[best] && ns < best {
+			best = ns
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	last := len(parts) - 1
+	parts[last] = strings.TrimSuffix(parts[last], path.Ext(parts[last]))
+	// the top-level directory, such as routes or components, says nothing about the file
+	for i := last; i >= min(1, last); i-- {
+		// flat route names such as edit.$id, without params, pathless layouts and index routes
+		var segments []string
+		for _, s := range strings.Split(strings.TrimSuffix(parts[i], "+"), ".") {
+			if s != "" && s[0] != '$' && s[0] != '_' && s != "index" && s != "route" {
+				segments = append(segments, s)
+			}
+		}
+		if ns := snakeCase(strings.Join(segments, "_")); ns != "" {
+			return ns
+		}
+	}
+	return "common"
+}
+
+// snakeCase converts a file or directory name such as TreeView or api-key to tree_view and api_key.
+func snakeCase(name string) string {
+	var b strings.Builder
+	prevLower := false
+	for _, r := range name {
+		switch {
+		case r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			prevLower = false
+		case unicode.IsUpper(r):
+			if prevLower {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			prevLower = false
+		default:
+			b.WriteRune(r)
+			prevLower = true
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+// codeEdit inserts the generated code of e before the first property of the call, on a line of its own if the first property is on its own line.
+func codeEdit(data []byte, e extractor.Entry) edit {
+	prop := fmt.Sprintf("code: %s,", jsString(e.Code))
+	start := e.CodePos
+	for start > 0 && strings.IndexByte(" \t\r\n", data[start-1]) >= 0 {
+		start--
+	}
+	space := string(data[start:e.CodePos])
+	i := strings.LastIndexByte(space, '\n')
+	if i < 0 {
+		return edit{pos: e.CodePos, end: e.CodePos, text: prop + " "}
+	}
+	newline := "\n"
+	if i > 0 && space[i-1] == '\r' {
+		newline = "\r\n"
+	}
+	return edit{pos: e.CodePos, end: e.CodePos, text: prop + newline + space[i+1:]}
+}
+
+// insertCodes writes the generated codes into the source files of root r and returns the number of changed files.
+func insertCodes(r root, generated []extractor.Entry) (int, error) {
+	byFile := make(map[string][]extractor.Entry)
+	var files []string
+	for _, e := range generated {
+		file, _, _ := e.Position()
+		if byFile[file] == nil {
+			files = append(files, file)
+		}
+		byFile[file] = append(byFile[file], e)
+	}
+	for i, file := range files {
+		path := filepath.Join(r.dir, file)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return i, err
+		}
+		var edits []edit
+		for _, e := range byFile[file] {
+			edits = append(edits, codeEdit(data, e))
+		}
+		if err := writeAtomically(path, applyEdits(data, edits)); err != nil {
+			return i, err
+		}
+	}
+	return len(files), nil
+}
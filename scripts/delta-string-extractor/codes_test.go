@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Save draft":                          "save_draft",
+		"Hello, {name}!":                      "hello_name",
+		"Are you sure you want to delete it?": "are_you_sure_you_want",
+		"Décès":                               "d_c_s",
+		"—":                                   "text_" + messageHash("—"),
+	}
+	for text, want := range cases {
+		if got := slugify(text); got != want {
+			t.Errorf("slugify(%q): wanted %q, got %q", text, want, got)
+		}
+	}
+}
+
+func TestFileNamespace(t *testing.T) {
+	entries := []extractor.Entry{
+		{Code: "hazard.title", Location: "routes/a.tsx:1"},
+		{Code: "common.save", Location: "routes/a.tsx:2"},
+		{Code: "hazard.name", Location: "routes/a.tsx:3"},
+		{Code: "other.name", Location: "routes/b.tsx:3"},
+	}
+	cases := map[string]string{
+		"routes/a.tsx":                                "hazard",
+		"components/TreeView.tsx":                     "tree_view",
+		"routes/$lang+/settings+/api-key+/_index.tsx": "api_key",
+		"routes/$lang+/hazard+/edit.$id.tsx":          "edit",
+		"routes/$lang+/_index.tsx":                    "common",
+	}
+	for file, want := range cases {
+		if got := fileNamespace(file, entries); got != want {
+			t.Errorf("fileNamespace(%q): wanted %q, got %q", file, want, got)
+		}
+	}
+}
+
+func TestCodeGenerator(t *testing.T) {
+	g := newCodeGenerator()
+	g.addEntries([]extractor.Entry{{Code: "ns.save", Msg: "Save"}, {Code: "ns.items", Msgs: map[string]string{"one": "Item"}}})
+
+	cases := []struct{ key, msg, want string }{
+		{"save", "Save", "ns.save"},
+		{"save", "Save!", "ns.save_2"},
+		{"save", "Save?", "ns.save_3"},
+		{"save", "Save!", "ns.save_2"},
+		{"items", "Items", "ns.items_2"},
+		{"cancel", "Cancel", "ns.cancel"},
+	}
+	for _, c := range cases {
+		if got := g.code("ns", c.key, messageID(c.msg, "")); got != c.want {
+			t.Errorf("code(%q, %q): wanted %q, got %q", c.key, c.msg, c.want, got)
+		}
+	}
+}
+
+func TestGenerateCodes(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "en.json")
+	committed := `[{"id": "hazard.save", "description": "", "translation": "Save draft"}]`
+	if err := os.WriteFile(outputFile, []byte(committed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries := func() []extractor.Entry {
+		return []extractor.Entry{
+			{Code: "hazard.title", Msg: "Hazard", Location: "routes/hazard.tsx:1"},
+			{Msg: "Save", Location: "routes/hazard.tsx:2", CodePos: 10},
+			{Msg: "Save draft", Location: "routes/hazard.tsx:3", CodePos: 20},
+			{Msg: "Save", Context: "verb", Location: "routes/hazard.tsx:4", CodePos: 30},
+			{Msgs: map[string]string{"one": "{n} item", "other": "{n} items"}, Location: "components/ItemList.tsx:1", CodePos: 5},
+		}
+	}
+
+	got := entries()
+	generated, err := generateCodes(got, outputFile, generateCodesSlug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"hazard.title", "hazard.save_2", "hazard.save_draft", "hazard.save_3", "item_list.n_items"}
+	for i, e := range got {
+		if e.Code != want[i] {
+			t.Errorf("slug %d: wanted %q, got %q", i, want[i], e.Code)
+		}
+	}
+	if len(generated) != 4 || generated[0].CodePos != 10 {
+		t.Errorf("wanted the 4 generated entries, got %v", generated)
+	}
+
+	got = entries()
+	if _, err := generateCodes(got, outputFile, generateCodesHash); err != nil {
+		t.Fatal(err)
+	}
+	if want := "hazard." + messageHash(messageID("Save", "")); got[1].Code != want {
+		t.Errorf("hash: wanted %q, got %q", want, got[1].Code)
+	}
+	if got[1].Code == got[3].Code {
+		t.Errorf("hash: wanted different codes for different contexts, got %q", got[1].Code)
+	}
+}
+
+func TestCodeEdit(t *testing.T) {
+	cases := []struct{ src, want string }{
+		{`t({ msg: "Save" })`, `t({ code: "a.save", msg: "Save" })`},
+		{`t({msg: "Save"})`, `t({code: "a.save", msg: "Save"})`},
+		{"t({\n\t\tmsg: \"Save\",\n\t})", "t({\n\t\tcode: \"a.save\",\n\t\tmsg: \"Save\",\n\t})"},
+		{"t({\r\n  msg: \"Save\"\r\n})", "t({\r\n  code: \"a.save\",\r\n  msg: \"Save\"\r\n})"},
+	}
+	for _, c := range cases {
+		e := extractor.Entry{Code: "a.save", CodePos: strings.Index(c.src, "msg")}
+		if got := string(applyEdits([]byte(c.src), []edit{codeEdit([]byte(c.src), e)})); got != c.want {
+			t.Errorf("codeEdit(%q): wanted %q, got %q", c.src, c.want, got)
+		}
+	}
+}
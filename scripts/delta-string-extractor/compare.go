@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"delta-string-extractor/extractor"
+)
+
+// runCompareTS runs scripts/extractor-i18n.ts and this extractor in --compat=ts mode on the same tree and reports every id whose translation or description differs.
+func runCompareTS(args []string) error {
+	fs := flag.NewFlagSet("compare-ts", flag.ExitOnError)
+	ef := registerExtractFlags(fs)
+	tsOutput := fs.String("ts-output", "", "output file of an earlier run of the TypeScript extractor, compared instead of running it")
+	tsCommand := fs.String("ts-command", "npx tsx ./scripts/extractor-i18n.ts", "command running the TypeScript extractor in the repository root, split at spaces and run without a shell")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := ef.config()
+	if err != nil {
+		return err
+	}
+	cfg.TSCompat = true
+	roots := ef.roots(root{})
+	if len(roots) != 1 {
+		return errors.New("compare-ts compares a single directory, pass --dir")
+	}
+	r := roots[0]
+
+	var tsData []byte
+	if *tsOutput != "" {
+		tsData, err = os.ReadFile(*tsOutput)
+	} else {
+		tsData, err = runTSExtractor(*tsCommand, r.dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	cache := loadFileCache(*ef.cacheFile, cacheFingerprint(cfg))
+	results, err := extractRoot(r, cfg, cache, *ef.workers)
+	if err != nil {
+		return err
+	}
+	if err := cache.save(); err != nil {
+		fmt.Fprintln(logw, "failed to save cache", err)
+	}
+	var entries []extractor.Entry
+	for _, res := range results {
+		entries = append(entries, res.tsEntries...)
+	}
+	goData := renderEntriesTS(entries, outputOptions{compat: compatTS}.forRoot(r).pathPrefix)
+
+	tsEntries, err := parseFileEntries(tsData)
+	if err != nil {
+		return fmt.Errorf("failed to parse the TypeScript output: %w", err)
+	}
+	goEntries, err := parseFileEntries(goData)
+	if err != nil {
+		return err
+	}
+	diffs := diffFileEntries(tsEntries, goEntries)
+	if len(diffs) != 0 {
+		printDiff(logw, "extractor-i18n.ts", "delta-string-extractor --compat=ts", diffs)
+	}
+	fmt.Fprintf(logw, "Ids compared %d, different %d\n", len(tsEntries), len(diffs))
+	switch {
+	case len(diffs) != 0:
+		return fmt.Errorf("%d ids differ", len(diffs))
+	case !bytes.Equal(tsData, goData):
+		return errors.New("the ids match but the files differ in formatting or order")
+	}
+	fmt.Fprintln(logw, "The outputs are identical")
+	return nil
+}
+
+// runTSExtractor runs the TypeScript extractor in the root of the repository containing dir and returns its output. It always writes locales/app/en.json, the file is restored afterwards.
+func runTSExtractor(command, dir string) ([]byte, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := findRepoRoot(abs)
+	if repo == "" {
+		return nil, fmt.Errorf("%s is not in a git repository, pass --ts-output", dir)
+	}
+	outFile := filepath.Join(repo, "locales", "app", "en.json")
+	backup, err := os.ReadFile(outFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	existed := err == nil
+	defer func() {
+		var err error
+		if existed {
+			err = writeAtomically(outFile, backup)
+		} else {
+			err = os.Remove(outFile)
+		}
+		if err != nil {
+			fmt.Fprintf(logw, "failed to restore %s: %v\n", outFile, err)
+		}
+	}()
+
+	// run without a shell, so it works on Windows as well
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("--ts-command is empty")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = repo
+	cmd.Stdout = logw
+	cmd.Stderr = logw
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w", command, err)
+	}
+	return os.ReadFile(outFile)
+}
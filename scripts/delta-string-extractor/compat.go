@@ -0,0 +1,222 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"delta-string-extractor/extractor"
+)
+
+// compatTS reproduces the output of scripts/extractor-i18n.ts.
+const compatTS = "ts"
+
+func validateCompat(compat string) error {
+	switch compat {
+	case "", compatTS:
+		return nil
+	}
+	return fmt.Errorf("invalid --compat %q, use ts", compat)
+}
+
+// renderEntriesTS returns the output file as scripts/extractor-i18n.ts writes it, from the entries of extractor.ExtractTS:
+//   - the first call of a code in the file order of ts-morph wins, see tsFileLess
+//   - msg is preferred over msgs, descriptions are only the desc property and the location of the first call
+//   - plural forms in the order of Entry.Forms
+//   - paths are relative to the repository root, the TypeScript extractor runs there
+//   - sorted by String.prototype.localeCompare, see localeCompare
+//   - JSON.stringify with an indent of 2, < and > escaped, no newline at the end
+func renderEntriesTS(entries []extractor.Entry, pathPrefix string) []byte {
+	type tsEntry struct {
+		id          string
+		msg         string
+		msgs        map[string]string
+		forms       []string
+		description string
+	}
+
+	calls := make([]extractor.Entry, len(entries))
+	copy(calls, entries)
+	sort.SliceStable(calls, func(i, j int) bool {
+		fa, la, ca := calls[i].Position()
+		fb, lb, cb := calls[j].Position()
+		if fa != fb {
+			return tsFileLess(filepath.ToSlash(fa), filepath.ToSlash(fb))
+		}
+		if la != lb {
+			return la < lb
+		}
+		return ca < cb
+	})
+
+	seen := make(map[string]bool)
+	var out []tsEntry
+	for _, e := range calls {
+		if seen[e.Code] || e.Msg == "" && len(e.Msgs) == 0 {
+			continue
+		}
+		seen[e.Code] = true
+
+		file, line, _ := e.Position()
+		desc := fmt.Sprintf("File: %s:%d", path.Join(pathPrefix, filepath.ToSlash(file)), line)
+		if e.Desc != "" {
+			desc = e.Desc + " " + desc
+		}
+		te := tsEntry{id: e.Code, msg: e.Msg, description: desc}
+		if e.Msg == "" {
+			te.msgs, te.forms = e.Msgs, e.Forms
+		}
+		out = append(out, te)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return localeCompare(out[i].id, out[j].id, false) < 0
+	})
+
+	var b strings.Builder
+	if len(out) == 0 {
+		b.WriteString("[]")
+	} else {
+		b.WriteString("[\n")
+		for i, e := range out {
+			b.WriteString("  {\n")
+			fmt.Fprintf(&b, "    \"id\": %s,\n", jsString(e.id))
+			b.WriteString("    \"translation\": ")
+			if e.msg == "" {
+				b.WriteString("{\n")
+				for k, form := range e.forms {
+					fmt.Fprintf(&b, "      %s: %s", jsString(form), jsString(e.msgs[form]))
+					if k < len(e.forms)-1 {
+						b.WriteString(",")
+					}
+					b.WriteString("\n")
+				}
+				b.WriteString("    },\n")
+			} else {
+				fmt.Fprintf(&b, "%s,\n", jsString(e.msg))
+			}
+			fmt.Fprintf(&b, "    \"description\": %s\n", jsString(e.description))
+			b.WriteString("  }")
+			if i < len(out)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("]")
+	}
+	res := strings.ReplaceAll(b.String(), "<", `\u003c`)
+	return []byte(strings.ReplaceAll(res, ">", `\u003e`))
+}
+
+// jsString quotes s like JSON.stringify. Unlike encoding/json it does not escape &, U+2028 and U+2029.
+func jsString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// tsFileLess orders slash separated paths like ts-morph lists source files: directories by depth, then by path, and files of a directory by name. Names are compared by localeCompare with upper case first, as ts-morph does.
+func tsFileLess(a, b string) bool {
+	dirA, nameA := path.Split(a)
+	dirB, nameB := path.Split(b)
+	partsA := strings.Split(strings.TrimSuffix(dirA, "/"), "/")
+	partsB := strings.Split(strings.TrimSuffix(dirB, "/"), "/")
+	if dirA == "" {
+		partsA = nil
+	}
+	if dirB == "" {
+		partsB = nil
+	}
+	if len(partsA) != len(partsB) {
+		return len(partsA) < len(partsB)
+	}
+	for i := range partsA {
+		if c := localeCompare(partsA[i], partsB[i], true); c != 0 {
+			return c < 0
+		}
+	}
+	return localeCompare(nameA, nameB, true) < 0
+}
+
+// collationOrder is the order of ASCII whitespace, punctuation, symbols and digits in the root collation of ICU, which Node uses for localeCompare. Letters follow, ignoring case.
+const collationOrder = "\t\n\v\f\r _-,;:!?.'\"()[]{}@*/\\&#%`^+<=>|~$0123456789"
+
+// collationKey returns the primary weight and the case of a rune. Other control characters are ignored.
+func collationKey(r rune) (weight int, upper, ok bool) {
+	if i := strings.IndexRune(collationOrder, r); i >= 0 {
+		return i + 1, false, true
+	}
+	switch {
+	case r >= 'a' && r <= 'z':
+		return len(collationOrder) + 1 + int(r-'a'), false, true
+	case r >= 'A' && r <= 'Z':
+		return len(collationOrder) + 1 + int(r-'A'), true, true
+	case r < 0x20 || r == 0x7f:
+		return 0, false, false
+	}
+	// other characters after the ASCII letters in code point order, good enough for ids and paths
+	return len(collationOrder) + 27 + int(r), false, true
+}
+
+// localeCompare compares like String.prototype.localeCompare in Node for ASCII strings: punctuation is not ignored, letters are compared without case first and lower case sorts first, or upper case with upperFirst.
+func localeCompare(a, b string, upperFirst bool) int {
+	type key struct {
+		weight int
+		upper  bool
+	}
+	keys := func(s string) []key {
+		res := make([]key, 0, utf8.RuneCountInString(s))
+		for _, r := range s {
+			if w, upper, ok := collationKey(r); ok {
+				res = append(res, key{w, upper})
+			}
+		}
+		return res
+	}
+	ka, kb := keys(a), keys(b)
+	for i := 0; i < len(ka) && i < len(kb); i++ {
+		if ka[i].weight != kb[i].weight {
+			return cmp.Compare(ka[i].weight, kb[i].weight)
+		}
+	}
+	if len(ka) != len(kb) {
+		return cmp.Compare(len(ka), len(kb))
+	}
+	for i := range ka {
+		if ka[i].upper != kb[i].upper {
+			if ka[i].upper == upperFirst {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
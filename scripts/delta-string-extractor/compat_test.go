@@ -0,0 +1,70 @@
+package main
+
+import (
+	"sort"
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestLocaleCompare(t *testing.T) {
+	// in the order of String.prototype.localeCompare
+	ids := []string{"a", "A", "a_b", "a-b", "a.b", "a1", "ab", "aB", "Ab", "b", "user_select", "user.confirm", "users.password_changed", "users.password.lowercase"}
+	got := make([]string, len(ids))
+	copy(got, ids)
+	sort.Slice(got, func(i, j int) bool { return localeCompare(got[i], got[j], false) < 0 })
+	for i := range ids {
+		if ids[i] != got[i] {
+			t.Fatalf("wanted %v, got %v", ids, got)
+		}
+	}
+	if localeCompare("Ab", "ab", true) >= 0 {
+		t.Errorf("wanted upper case first")
+	}
+}
+
+func TestTSFileLess(t *testing.T) {
+	// in the order of ts-morph: by directory depth first
+	files := []string{"root.ts", "a/z.ts", "b/a.tsx", "a/sub/a.ts", "routes/$lang+/_layout.tsx", "routes/$lang+/$id.tsx", "routes/$lang+/edit.tsx"}
+	for i := 1; i < len(files); i++ {
+		if !tsFileLess(files[i-1], files[i]) || tsFileLess(files[i], files[i-1]) {
+			t.Errorf("wanted %s before %s", files[i-1], files[i])
+		}
+	}
+}
+
+func TestRenderEntriesTS(t *testing.T) {
+	entries := []extractor.Entry{
+		{Code: "b", Msg: "Deep", Location: "a/sub/x.tsx:1", Column: 1, Callee: "ctx.t"},
+		{Code: "b", Msg: "Shallow <b>", Desc: "Bold", Note: "not in the output", Location: "a/y.tsx:9", Column: 1, Callee: "ctx.t"},
+		{Code: "a_b", Msgs: map[string]string{"other": "Many", "one": "One"}, Forms: []string{"other", "one"}, Location: "a/y.tsx:3", Column: 1, Callee: "backendCtx.t"},
+		{Code: "c", Msg: "Tab\there & \"quoted\"", Msgs: map[string]string{"one": "x"}, Forms: []string{"one"}, Location: "a/y.tsx:5", Column: 1, Callee: "ctx.t"},
+	}
+	got := string(renderEntriesTS(entries, "app"))
+	want := `[
+  {
+    "id": "a_b",
+    "translation": {
+      "other": "Many",
+      "one": "One"
+    },
+    "description": "File: app/a/y.tsx:3"
+  },
+  {
+    "id": "b",
+    "translation": "Shallow \u003cb\u003e",
+    "description": "Bold File: app/a/y.tsx:9"
+  },
+  {
+    "id": "c",
+    "translation": "Tab\there & \"quoted\"",
+    "description": "File: app/a/y.tsx:5"
+  }
+]`
+	if got != want {
+		t.Errorf("wanted\n%s\ngot\n%s", want, got)
+	}
+	if got := string(renderEntriesTS(nil, "app")); got != "[]" {
+		t.Errorf("wanted [], got %s", got)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"delta-string-extractor/extractor"
+)
+
+type CommaSeparated []string
+
+func (l *CommaSeparated) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *CommaSeparated) Set(value string) error {
+	*l = strings.Split(value, ",")
+	return nil
+}
+
+// fileConfig is the format of the optional --config JSON file. Flags that are set override values from the file.
+type fileConfig struct {
+	// Callees are callee patterns of translation calls, for example ["ctx.t", "*.t"]
+	Callees []string `json:"callees"`
+	// ExcludeCallees are callee patterns that are never translation calls
+	ExcludeCallees []string `json:"excludeCallees"`
+	// Severities sets the severity of diagnostics by rule, for example {"non-literal": "warning"}
+	Severities map[string]string `json:"severities"`
+	// Naming are the conventions codes are checked against, see extractor.NamingRules
+	Naming extractor.NamingRules `json:"naming"`
+	// Hardcoded configures the hardcoded command, see extractor.HardcodedRules
+	Hardcoded extractor.HardcodedRules `json:"hardcoded"`
+	// Roots are the directories to extract, each into its own output file. Paths are relative to the config file.
+	Roots []rootConfig `json:"roots"`
+}
+
+// fileFilter selects the files of a root to extract. Patterns are matched against the slash separated path relative to the root, ** matches any number of directories.
+type fileFilter struct {
+	// Extensions of files to extract, for example [".ts", ".tsx", ".mdx"]
+	Extensions []string `json:"extensions,omitempty"`
+	// Include limits extraction to matching files, empty for all files
+	Include []string `json:"include,omitempty"`
+	// Exclude skips matching files and directories
+	Exclude []string `json:"exclude,omitempty"`
+	// Gitignore skips files ignored by .gitignore files, default true
+	Gitignore *bool `json:"gitignore,omitempty"`
+}
+
+// Defaults of fileFilter.
+var (
+	defaultExtensions = []string{".ts", ".tsx"}
+	defaultExclude    = []string{"**/node_modules"}
+)
+
+// withDefaults fills unset fields of the filter.
+func (f fileFilter) withDefaults() fileFilter {
+	if len(f.Extensions) == 0 {
+		f.Extensions = defaultExtensions
+	}
+	if f.Exclude == nil {
+		f.Exclude = defaultExclude
+	}
+	if f.Gitignore == nil {
+		gitignore := true
+		f.Gitignore = &gitignore
+	}
+	return f
+}
+
+type rootConfig struct {
+	Dir        string `json:"dir"`
+	OutputFile string `json:"outputFile"`
+	UsageFile  string `json:"usageFile,omitempty"`
+	TypesFile  string `json:"typesFile,omitempty"`
+	fileFilter
+}
+
+// root is a directory extracted into one output file.
+type root struct {
+	dir        string
+	outputFile string
+	usageFile  string
+	// typesFile is the TypeScript declarations file of the codes, see renderTypes
+	typesFile string
+	filter    fileFilter
+}
+
+func readFileConfig(path string) (fileConfig, error) {
+	var cfg fileConfig
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// extractorConfig merges the config file with the flags on top of extractor.DefaultConfig.
+func extractorConfig(fc fileConfig, callees, excludeCallees, severities CommaSeparated) (extractor.Config, error) {
+	cfg := extractor.DefaultConfig()
+	if len(fc.Callees) != 0 {
+		cfg.Callees.Include = fc.Callees
+	}
+	if len(fc.ExcludeCallees) != 0 {
+		cfg.Callees.Exclude = fc.ExcludeCallees
+	}
+	if len(callees) != 0 {
+		cfg.Callees.Include = callees
+	}
+	if len(excludeCallees) != 0 {
+		cfg.Callees.Exclude = excludeCallees
+	}
+	if err := cfg.Callees.Validate(); err != nil {
+		return cfg, err
+	}
+	cfg.Naming = fc.Naming
+	if err := cfg.Naming.Validate(); err != nil {
+		return cfg, err
+	}
+	cfg.Hardcoded = fc.Hardcoded
+	if err := cfg.Hardcoded.Validate(); err != nil {
+		return cfg, err
+	}
+
+	cfg.Severities = make(map[string]extractor.Severity)
+	for rule, s := range fc.Severities {
+		sev, err := extractor.ParseSeverity(s)
+		if err != nil {
+			return cfg, fmt.Errorf("rule %s: %w", rule, err)
+		}
+		cfg.Severities[rule] = sev
+	}
+	for _, s := range severities {
+		rule, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return cfg, fmt.Errorf("invalid --severity %q, use rule=severity", s)
+		}
+		sev, err := extractor.ParseSeverity(value)
+		if err != nil {
+			return cfg, fmt.Errorf("rule %s: %w", rule, err)
+		}
+		cfg.Severities[rule] = sev
+	}
+	return cfg, nil
+}
+
+// extractFlags are the flags shared by all commands that extract strings.
+type extractFlags struct {
+	dir             *string
+	configFile      *string
+	cacheFile       *string
+	workers         *int
+	format          *string
+	diagnosticsFile *string
+	callees         CommaSeparated
+	excludeCallees  CommaSeparated
+	severities      CommaSeparated
+	extensions      CommaSeparated
+	include         CommaSeparated
+	exclude         CommaSeparated
+	gitignore       *bool
+
+	fs   *flag.FlagSet
+	file fileConfig
+}
+
+func registerExtractFlags(fs *flag.FlagSet) *extractFlags {
+	f := &extractFlags{
+		dir:             fs.String("dir", "../../app", "directory to scan for files"),
+		configFile:      fs.String("config", "", "optional JSON config file"),
+		cacheFile:       fs.String("cache-file", defaultCacheFile(), "file to cache extraction results of unchanged files in, empty to disable"),
+		workers:         fs.Int("workers", runtime.NumCPU(), "number of files to extract in parallel"),
+		format:          fs.String("format", formatText, "diagnostics format: text, json or sarif"),
+		diagnosticsFile: fs.String("diagnostics-file", "", "file to write diagnostics to, default stdout"),
+		gitignore:       fs.Bool("gitignore", true, "skip files ignored by .gitignore"),
+		fs:              fs,
+	}
+	fs.Var(&f.callees, "callees", "Comma-separated callee patterns of translation calls (default t,*.t)")
+	fs.Var(&f.excludeCallees, "exclude-callees", "Comma-separated callee patterns to ignore")
+	fs.Var(&f.severities, "severity", "Comma-separated rule=severity pairs, severity is error, warning or off (e.g. non-literal=warning)")
+	fs.Var(&f.extensions, "extensions", "Comma-separated extensions of files to extract (default .ts,.tsx)")
+	fs.Var(&f.include, "include", "Comma-separated glob patterns of files to extract, relative to --dir (e.g. routes/**)")
+	fs.Var(&f.exclude, "exclude", "Comma-separated glob patterns of files and directories to skip (default **/node_modules)")
+	return f
+}
+
+// config loads the config file and applies the flags.
+func (f *extractFlags) config() (extractor.Config, error) {
+	if err := validateFormat(*f.format); err != nil {
+		return extractor.Config{}, err
+	}
+	if *f.format != formatText && *f.diagnosticsFile == "" {
+		// keep stdout for the diagnostics
+		logw = os.Stderr
+	}
+	fc, err := readFileConfig(*f.configFile)
+	if err != nil {
+		return extractor.Config{}, err
+	}
+	f.file = fc
+	return extractorConfig(fc, f.callees, f.excludeCallees, f.severities)
+}
+
+// isSet reports whether the flag was passed on the command line.
+func (f *extractFlags) isSet(name string) bool {
+	set := false
+	f.fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// roots returns the roots of the config file, or a single root from --dir and the output files of the command's flags in outputs. The filter flags apply to all roots. It must be called after config.
+func (f *extractFlags) roots(outputs root) []root {
+	var res []root
+	fromFlags := f.isSet("dir") || f.isSet("output-file") || f.isSet("usage-file") || f.isSet("types-file")
+	if len(f.file.Roots) == 0 || fromFlags {
+		outputs.dir = *f.dir
+		res = []root{outputs}
+	} else {
+		base := filepath.Dir(*f.configFile)
+		resolve := func(p string) string {
+			if p == "" || filepath.IsAbs(p) {
+				return p
+			}
+			return filepath.Join(base, filepath.FromSlash(p))
+		}
+		for _, rc := range f.file.Roots {
+			res = append(res, root{
+				dir:        resolve(rc.Dir),
+				outputFile: resolve(rc.OutputFile),
+				usageFile:  resolve(rc.UsageFile),
+				typesFile:  resolve(rc.TypesFile),
+				filter:     rc.fileFilter,
+			})
+		}
+	}
+	for i := range res {
+		filter := &res[i].filter
+		if len(f.extensions) != 0 {
+			filter.Extensions = f.extensions
+		}
+		if len(f.include) != 0 {
+			filter.Include = f.include
+		}
+		if len(f.exclude) != 0 {
+			filter.Exclude = f.exclude
+		}
+		if f.isSet("gitignore") {
+			filter.Gitignore = f.gitignore
+		}
+		*filter = filter.withDefaults()
+	}
+	return res
+}
+
+// writeDiagnostics sorts the diagnostics and writes them in --format to --diagnostics-file or stdout. The paths of the diagnostics are relative to dir.
+func (f *extractFlags) writeDiagnostics(dir string, diags []extractor.Diagnostic) error {
+	extractor.SortDiagnostics(diags)
+	if *f.diagnosticsFile == "" {
+		return writeDiagnostics(os.Stdout, *f.format, dir, diags)
+	}
+	var buf bytes.Buffer
+	if err := writeDiagnostics(&buf, *f.format, dir, diags); err != nil {
+		return err
+	}
+	return writeAtomically(*f.diagnosticsFile, buf.Bytes())
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"delta-string-extractor/extractor"
+)
+
+// logw receives messages for people, such as progress and summaries. It is stderr when diagnostics are written to stdout in a machine readable format, so they can be redirected to a file.
+var logw io.Writer = os.Stdout
+
+// Diagnostic output formats.
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatSARIF = "sarif"
+)
+
+func validateFormat(format string) error {
+	switch format {
+	case formatText, formatJSON, formatSARIF:
+		return nil
+	}
+	return fmt.Errorf("invalid --format %q, use text, json or sarif", format)
+}
+
+// printDiagnostics prints one line per diagnostic, as file:line:column: severity: message [rule].
+func printDiagnostics(diags []extractor.Diagnostic) {
+	for _, d := range diags {
+		fmt.Fprintln(logw, d)
+	}
+}
+
+// writeDiagnostics writes the diagnostics of a run in the given format. dir is the scanned directory, the diagnostic file paths are relative to it.
+func writeDiagnostics(w io.Writer, format, dir string, diags []extractor.Diagnostic) error {
+	switch format {
+	case formatJSON:
+		if diags == nil {
+			diags = []extractor.Diagnostic{}
+		}
+		data, err := json.MarshalIndent(diags, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case formatSARIF:
+		data, err := json.MarshalIndent(sarifLog(diags, repoPath(dir)), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	default:
+		for _, d := range diags {
+			if _, err := fmt.Fprintln(w, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SARIF 2.1.0 types, only the parts used here. See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool sarifTool `json:"tool"`
+	// ColumnKind is unicodeCodePoints, the columns of diagnostics count runes, not UTF-16 code units
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+func sarifLevel(sev extractor.Severity) string {
+	if sev == extractor.SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// sarifLog converts diagnostics to a SARIF log. uriPrefix is prepended to the file paths, so they are relative to the repository root as code scanning tools expect.
+func sarifLog(diags []extractor.Diagnostic, uriPrefix string) sarifReport {
+	ids := make([]string, 0, len(extractor.RuleDescriptions))
+	for id := range extractor.RuleDescriptions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	rules := make([]sarifRule, 0, len(ids))
+	ruleIndex := make(map[string]int)
+	for i, id := range ids {
+		ruleIndex[id] = i
+		rules = append(rules, sarifRule{
+			ID:                   id,
+			ShortDescription:     sarifMessage{Text: extractor.RuleDescriptions[id]},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(extractor.DefaultSeverities[id])},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(diags))
+	for _, d := range diags {
+		loc := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: path.Join(uriPrefix, filepath.ToSlash(d.File))},
+		}
+		if d.Line > 0 {
+			loc.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column}
+		}
+		results = append(results, sarifResult{
+			RuleID:    d.Rule,
+			RuleIndex: ruleIndex[d.Rule],
+			Level:     sarifLevel(d.Severity),
+			Message:   sarifMessage{Text: d.Message},
+			Locations: []sarifLocation{{PhysicalLocation: loc}},
+		})
+	}
+
+	return sarifReport{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:       sarifTool{Driver: sarifDriver{Name: "delta-string-extractor", Rules: rules}},
+			ColumnKind: "unicodeCodePoints",
+			Results:    results,
+		}},
+	}
+}
+
+// repoPath returns the path of dir relative to the root of the git repository containing it, for example app. Outside of a repository it is empty, paths stay relative to dir.
+func repoPath(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	root := findRepoRoot(abs)
+	if root == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// findRepoRoot returns the root of the git repository containing the absolute path dir, or an empty string outside of a repository.
+func findRepoRoot(dir string) string {
+	for root := dir; ; {
+		if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+			return root
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return ""
+		}
+		root = parent
+	}
+}
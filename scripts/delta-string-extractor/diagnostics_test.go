@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestSarifLog(t *testing.T) {
+	diags := []extractor.Diagnostic{
+		{Rule: extractor.RuleBadPlural, Severity: extractor.SeverityWarning, File: "routes/a.tsx", Line: 3, Column: 5, Message: "bad"},
+		{Rule: extractor.RuleReadError, Severity: extractor.SeverityError, File: "b.tsx", Message: "permission denied"},
+	}
+	log := sarifLog(diags, "app")
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("wanted one SARIF 2.1.0 run, got %+v", log)
+	}
+	run := log.Runs[0]
+	if run.ColumnKind != "unicodeCodePoints" {
+		t.Errorf("wanted columns in code points, got %q", run.ColumnKind)
+	}
+	if len(run.Tool.Driver.Rules) != len(extractor.RuleDescriptions) {
+		t.Errorf("wanted all rules, got %d", len(run.Tool.Driver.Rules))
+	}
+	got := run.Results[0]
+	if run.Tool.Driver.Rules[got.RuleIndex].ID != got.RuleID {
+		t.Errorf("rule index %d does not point to %s", got.RuleIndex, got.RuleID)
+	}
+	want := sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: "app/routes/a.tsx"},
+		Region:           &sarifRegion{StartLine: 3, StartColumn: 5},
+	}
+	if got.Level != "warning" || !reflect.DeepEqual(want, got.Locations[0].PhysicalLocation) {
+		t.Errorf("wanted warning at %+v, got %+v", want, got)
+	}
+	if loc := run.Results[1].Locations[0].PhysicalLocation; loc.Region != nil || loc.ArtifactLocation.URI != "app/b.tsx" {
+		t.Errorf("wanted file location without region, got %+v", loc)
+	}
+}
+
+func TestConflictDiagnostics(t *testing.T) {
+	entries := []extractor.Entry{
+		{Location: "a.tsx:1", Column: 3, Code: "common.search", Msg: "Search"},
+		{Location: "b.tsx:2", Column: 4, Code: "common.search", Msg: "Search"},
+		{Location: "c.tsx:3", Column: 5, Code: "common.search", Msg: "Search…"},
+	}
+	_, conflicts, err := renderEntriesJSON(entries, outputOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range conflictDiagnostics(conflicts, extractor.DefaultConfig()) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		`a.tsx:1:3: error: code "common.search" has conflicting messages: "Search" here, "Search…" at c.tsx:3 [conflicting-message]`,
+		`b.tsx:2:4: error: code "common.search" has conflicting messages: "Search" here, "Search…" at c.tsx:3 [conflicting-message]`,
+		`c.tsx:3:5: error: code "common.search" has conflicting messages: "Search…" here, "Search" at a.tsx:1, "Search" at b.tsx:2 [conflicting-message]`,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,86 @@
+package extractor
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// CalleeMatcher selects translation calls by their callee form. Patterns use path.Match syntax against the dotted callee, where * matches any sequence of characters. For example "ctx.t" matches only ctx.t(, "*.t" matches ctx.t( and props.ctx.t( and "t" matches a plain t(. Optional chaining is normalized, so ctx?.t( has the callee ctx.t. Calls and index expressions in the chain keep empty brackets, for example getCtx().t.
+type CalleeMatcher struct {
+	Include []string
+	Exclude []string
+}
+
+// Validate checks that all patterns are well formed.
+func (m CalleeMatcher) Validate() error {
+	for _, pattern := range append(append([]string{}, m.Include...), m.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid callee pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Match reports whether the callee matches any include pattern and no exclude pattern.
+func (m CalleeMatcher) Match(callee string) bool {
+	return matchAny(m.Include, callee) && !matchAny(m.Exclude, callee)
+}
+
+func matchAny(patterns []string, callee string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, callee); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// callee returns the callee form for the call whose identifier is at token i, and the index of the first token of the callee.
+func (p *parser) callee(i int) (string, int) {
+	start := i
+	parts := []string{p.tok(i).Text}
+	for j := i - 1; j > 0 && (p.isPunct(j, ".") || p.isPunct(j, "?.")); {
+		k := j - 1
+		suffix := ""
+		for p.isPunct(k, ")") || p.isPunct(k, "]") {
+			if p.isPunct(k, ")") {
+				suffix = "()" + suffix
+			} else {
+				suffix = "[]" + suffix
+			}
+			k = p.matchingOpen(k) - 1
+			// optional call or index, as in a?.()
+			if p.isPunct(k, "?.") {
+				k--
+			}
+		}
+		if k < 0 || p.tok(k).Kind != TokenIdent {
+			break
+		}
+		parts = append(parts, p.tok(k).Text+suffix)
+		start = k
+		j = k - 1
+	}
+	for l, r := 0, len(parts)-1; l < r; l, r = l+1, r-1 {
+		parts[l], parts[r] = parts[r], parts[l]
+	}
+	return strings.Join(parts, "."), start
+}
+
+// matchingOpen returns the index of the bracket opening the group closed at token i.
+func (p *parser) matchingOpen(i int) int {
+	depth := 0
+	for ; i >= 0; i-- {
+		t := p.tok(i)
+		if closesGroup(t) {
+			depth++
+		} else if opensGroup(t) {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package extractor
+
+import (
+	"sort"
+	"strings"
+)
+
+// translatorMarkers start a comment meant for translators. They are matched case-insensitively.
+var translatorMarkers = []string{"i18n:", "translators:"}
+
+// translatorNotes finds translator comments written right above translation calls, for example
+//
+//	// i18n: shown on the button that deletes the record
+//	ctx.t({ code: "common.delete", msg: "Delete" })
+type translatorNotes struct {
+	comments []Token
+	lines    *LineIndex
+}
+
+func newTranslatorNotes(toks []Token, lines *LineIndex) *translatorNotes {
+	n := &translatorNotes{lines: lines}
+	for _, t := range toks {
+		if t.Kind == TokenComment {
+			n.comments = append(n.comments, t)
+		}
+	}
+	return n
+}
+
+// before returns the note for a call starting at pos. The note is read from the block of comments that ends on the line above the call, or on the same line before it, starting at the first comment with a translator marker. Later comments of the block continue the note.
+func (n *translatorNotes) before(pos int) string {
+	// index of the last comment before pos
+	last := sort.Search(len(n.comments), func(i int) bool { return n.comments[i].End > pos }) - 1
+	if last < 0 {
+		return ""
+	}
+	callLine, _ := n.lines.Position(pos)
+	endLine, _ := n.lines.Position(n.comments[last].End - 1)
+	if endLine != callLine && endLine != callLine-1 {
+		return ""
+	}
+	first := last
+	for first > 0 {
+		prevEnd, _ := n.lines.Position(n.comments[first-1].End - 1)
+		start, _ := n.lines.Position(n.comments[first].Pos)
+		if prevEnd < start-1 {
+			break
+		}
+		first--
+	}
+
+	var parts []string
+	found := false
+	for _, c := range n.comments[first : last+1] {
+		text := commentText(c.Text)
+		if !found {
+			rest, ok := cutMarker(text)
+			if !ok {
+				continue
+			}
+			found = true
+			text = rest
+		}
+		if text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// commentText strips the comment delimiters and leading * of block comment lines, and collapses whitespace.
+func commentText(s string) string {
+	if rest, ok := strings.CutPrefix(s, "//"); ok {
+		return strings.Join(strings.Fields(rest), " ")
+	}
+	s = strings.TrimPrefix(s, "/*")
+	s = strings.TrimSuffix(s, "*/")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(strings.TrimSpace(line), "*")
+	}
+	return strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+}
+
+// cutMarker removes a translator marker from the start of a comment.
+func cutMarker(text string) (string, bool) {
+	lower := strings.ToLower(text)
+	for _, m := range translatorMarkers {
+		if strings.HasPrefix(lower, m) {
+			return strings.TrimSpace(text[len(m):]), true
+		}
+	}
+	return "", false
+}
+
+// JoinDesc joins descriptions with a space, skipping empty ones and ones already included.
+func JoinDesc(descs ...string) string {
+	var parts []string
+	for _, d := range descs {
+		d = strings.TrimSpace(d)
+		if d == "" || inSlice(parts, d) {
+			continue
+		}
+		parts = append(parts, d)
+	}
+	return strings.Join(parts, " ")
+}
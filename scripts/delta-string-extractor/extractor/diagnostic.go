@@ -0,0 +1,177 @@
+package extractor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityOff     Severity = "off"
+)
+
+// Rule ids of diagnostics.
+const (
+	// RuleNonLiteral is a translation call whose code, msg, msgs or context is not a literal, so it can not be extracted.
+	RuleNonLiteral = "non-literal"
+	// RuleInvalidLiteral is a translation call whose first argument could not be parsed.
+	RuleInvalidLiteral = "invalid-literal"
+	// RulePlaceholderMissing is a {placeholder} in the message without a matching key in the replacements argument.
+	RulePlaceholderMissing = "placeholder-missing"
+	// RulePlaceholderUnused is a key in the replacements argument that no message uses.
+	RulePlaceholderUnused = "placeholder-unused"
+	// RuleUnbalancedBraces is a message with a { that is not closed or a } that was not opened.
+	RuleUnbalancedBraces = "unbalanced-braces"
+	// RuleBadPlural is a plural message (msgs) that can not work at runtime, see checkPlural.
+	RuleBadPlural = "bad-plural"
+	// RuleCodeFormat is a code that does not match NamingRules.Pattern or has more than NamingRules.MaxDepth parts.
+	RuleCodeFormat = "code-format"
+	// RuleCodeNamespace is a code whose namespace is not in NamingRules.Namespaces.
+	RuleCodeNamespace = "code-namespace"
+	// RuleCodeDirectory is a code whose namespace does not match the directory of the call site.
+	RuleCodeDirectory = "code-directory"
+	// RuleCodeNearDuplicate is a code that only differs by case or separators from another code.
+	RuleCodeNearDuplicate = "code-near-duplicate"
+	// RuleMissingCode is a translation call without a code.
+	RuleMissingCode = "missing-code"
+	// RuleMissingMessage is a translation call without msg and without non-empty msgs.
+	RuleMissingMessage = "missing-msg"
+	// RuleConflictingMessage is a code used with different messages or contexts in different places.
+	RuleConflictingMessage = "conflicting-message"
+	// RuleReadError is a file that could not be read.
+	RuleReadError = "read-error"
+	// RuleHardcodedString is user-visible JSX text or attribute that is not translated, see FindHardcoded.
+	RuleHardcodedString = "hardcoded-string"
+	// RuleMissingPluralForm is a plural message whose translation lacks a form the language uses, or has it empty.
+	RuleMissingPluralForm = "missing-plural-form"
+)
+
+// RuleDescriptions are short descriptions of all rules, for example for SARIF output.
+var RuleDescriptions = map[string]string{
+	RuleNonLiteral:         "Translation call argument is not a literal and can not be extracted",
+	RuleInvalidLiteral:     "Translation call argument could not be parsed",
+	RulePlaceholderMissing: "Placeholder without a matching replacement",
+	RulePlaceholderUnused:  "Replacement not used by any message",
+	RuleUnbalancedBraces:   "Message with unbalanced braces",
+	RuleBadPlural:          "Plural message that can not work at runtime",
+	RuleCodeFormat:         "Code does not follow the naming pattern or depth",
+	RuleCodeNamespace:      "Code namespace is not allowed",
+	RuleCodeDirectory:      "Code namespace does not match the directory",
+	RuleCodeNearDuplicate:  "Code only differs by case or separators from another code",
+	RuleMissingCode:        "Translation call without a code",
+	RuleMissingMessage:     "Translation call without a message",
+	RuleConflictingMessage: "Code used with different messages",
+	RuleReadError:          "File could not be read",
+	RuleHardcodedString:    "User-visible text that is not translated",
+	RuleMissingPluralForm:  "Translation without a plural form its language uses",
+}
+
+// DefaultSeverities are used for rules not set in Config.Severities.
+var DefaultSeverities = map[string]Severity{
+	RuleNonLiteral:         SeverityError,
+	RuleInvalidLiteral:     SeverityError,
+	RulePlaceholderMissing: SeverityError,
+	RulePlaceholderUnused:  SeverityWarning,
+	RuleUnbalancedBraces:   SeverityError,
+	RuleBadPlural:          SeverityError,
+	RuleCodeFormat:         SeverityError,
+	RuleCodeNamespace:      SeverityError,
+	RuleCodeDirectory:      SeverityWarning,
+	RuleCodeNearDuplicate:  SeverityError,
+	RuleMissingCode:        SeverityError,
+	RuleMissingMessage:     SeverityError,
+	RuleConflictingMessage: SeverityError,
+	RuleReadError:          SeverityError,
+	RuleHardcodedString:    SeverityWarning,
+	RuleMissingPluralForm:  SeverityWarning,
+}
+
+// ParseSeverity validates a severity name.
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(strings.ToLower(s)); sev {
+	case SeverityError, SeverityWarning, SeverityOff:
+		return sev, nil
+	}
+	return "", fmt.Errorf("invalid severity %q, use error, warning or off", s)
+}
+
+// Diagnostic is a problem found in a source file.
+type Diagnostic struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	// Line and Column are 1-based, 0 if the diagnostic is about the whole file
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s [%s]", d.File, d.Severity, d.Message, d.Rule)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", d.File, d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// HasErrors reports whether any of the diagnostics has error severity.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// SortDiagnostics orders diagnostics by file and position.
+func SortDiagnostics(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// Severity returns the configured severity of a rule.
+func (c Config) Severity(rule string) Severity {
+	if sev, ok := c.Severities[rule]; ok {
+		return sev
+	}
+	if sev, ok := DefaultSeverities[rule]; ok {
+		return sev
+	}
+	return SeverityError
+}
+
+// diagnostics collects the diagnostics of a single file.
+type diagnostics struct {
+	cfg   Config
+	file  string
+	lines *LineIndex
+	list  []Diagnostic
+}
+
+func (d *diagnostics) add(rule string, pos int, format string, args ...any) {
+	sev := d.cfg.Severity(rule)
+	if sev == SeverityOff {
+		return
+	}
+	line, col := d.lines.Position(pos)
+	d.list = append(d.list, Diagnostic{
+		Rule:     rule,
+		Severity: sev,
+		File:     d.file,
+		Line:     line,
+		Column:   col,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
@@ -1,9 +1,8 @@
 package extractor
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -12,109 +11,326 @@ type Entry struct {
 	Code     string
 	Msg      string
 	Msgs     map[string]string
-	Desc     string
+	// Forms are the keys of Msgs in the order of the source
+	Forms []string
+	// Desc is the desc property
+	Desc string
+	// Note is the translator comment above the call, see translatorNotes
+	Note string
+	// Context disambiguates equal messages that need different translations, for example Close as a verb or an adjective
+	Context string
+	// Callee is the callee form of the call, for example ctx.t or backendCtx.t
+	Callee string
+	// Column is the 1-based column of the call on the line in Location
+	Column int
+	// Scope is the top-level declaration containing the call, usually the component or the loader or action of a route
+	Scope string
+	// CountKeys are the keys of the replacements of a plural message that may hold its number, see countKeys. Only set if the replacements are an object literal.
+	CountKeys []string
+	// CodePos is the byte offset of the first property of a call without a code, where a generated code can be inserted. Only set with Config.GenerateCodes.
+	CodePos int
 }
 
-type entryJSON struct {
-	Code string
-	Msg  any
-	Msgs map[string]any
-	Desc string
+// Description returns the desc property followed by the translator comment.
+func (e Entry) Description() string {
+	return JoinDesc(e.Desc, e.Note)
+}
+
+// Position returns the file, line and column of the call.
+func (e Entry) Position() (file string, line, column int) {
+	i := strings.LastIndex(e.Location, ":")
+	if i < 0 {
+		return e.Location, 0, e.Column
+	}
+	line, err := strconv.Atoi(e.Location[i+1:])
+	if err != nil {
+		return e.Location, 0, e.Column
+	}
+	return e.Location[:i], line, e.Column
+}
+
+// Config controls which calls are extracted.
+type Config struct {
+	Callees CalleeMatcher
+	// Severities overrides DefaultSeverities by rule id
+	Severities map[string]Severity
+	// Naming are the conventions codes are checked against
+	Naming NamingRules
+	// Hardcoded selects the literals reported by FindHardcoded
+	Hardcoded HardcodedRules
+	// GenerateCodes keeps calls without a code as entries with an empty Code and their CodePos, for the caller to generate codes, instead of reporting missing-code
+	GenerateCodes bool
+	// TSCompat also reads the calls the way scripts/extractor-i18n.ts does into Result.TSEntries, see ExtractTS
+	TSCompat bool
 }
 
-// countLines counts how many '\n' are in the data up to 'index'.
-func countLines(data []byte, index int) int {
-	if index > len(data) {
-		index = len(data)
+// DefaultConfig matches a plain t( call and any method named t, same as the extractor always did.
+func DefaultConfig() Config {
+	return Config{
+		Callees: CalleeMatcher{Include: []string{"t", "*.t"}},
 	}
-	lines := bytes.Count(data[:index], []byte("\n"))
-	return lines + 1 // 1-based line numbers
 }
 
+// Result is the outcome of extracting a single file.
+type Result struct {
+	Entries     []Entry
+	Diagnostics []Diagnostic
+	// TSEntries are only set with Config.TSCompat
+	TSEntries []Entry
+}
+
+// ExtractFromContent extracts translation entries using DefaultConfig. The first diagnostic with error severity is returned as the error.
 func ExtractFromContent(file string, data []byte) (res []Entry, rerr error) {
-	substr := []byte("t(")
-	ind := 0
-
-	for {
-		if ind >= len(data) {
-			break
-		}
-		part := data[ind:]
-		pos := bytes.Index(part, substr)
-		if pos == -1 {
-			break
-		}
-
-		// Check character before 't' to avoid matching 'text', 'gettext', etc.
-		prevIdx := ind + pos - 1
-		if prevIdx >= 0 {
-			prevChar := data[prevIdx]
-			if !isBoundary(prevChar) {
-				ind += pos + 1
-				continue
-			}
+	r := Extract(file, data, DefaultConfig())
+	for _, d := range r.Diagnostics {
+		if d.Severity == SeverityError {
+			return r.Entries, fmt.Errorf("%v", d)
 		}
+	}
+	return r.Entries, nil
+}
 
-		offset := pos + len(substr)
-		input := part[offset:]
-		dec := json.NewDecoder(bytes.NewReader(input))
-		var entryJSON entryJSON
-		err := dec.Decode(&entryJSON)
-		if err != nil {
-			rerr = fmt.Errorf("found t({, but invalid json after\nMake sure it is valid JSON, not JS, for example by encasing the keys into quotes\nerr: %v\ncontent: %v\n", err, truncate(string(input), 300))
-			return
+// Extract finds all translation calls in JS/TS source matching cfg.Callees and returns their translation entries. Calls inside strings, comments and regexes are ignored. Calls that can not be extracted statically, for example with a variable as code, are reported as diagnostics and do not stop the extraction of the rest of the file.
+func Extract(file string, data []byte, cfg Config) Result {
+	// positions in the code of MDX files are positions in data
+	code := data
+	if IsMDXFile(file) {
+		code = MDXCode(data)
+	}
+	toks := Tokenize(code, IsJSXFile(file))
+	p := newParser(code, toks)
+	lines := NewLineIndex(data)
+	notes := newTranslatorNotes(toks, lines)
+	scopes := p.scopes()
+	diags := &diagnostics{cfg: cfg, file: file, lines: lines}
+	naming := newNamingChecker(cfg.Naming, file)
+	var res []Entry
+
+	for i := 0; i < len(p.toks); i++ {
+		if !p.isCall(i) {
+			continue
 		}
-		lineNum := countLines(data, ind+pos)
-		var entry Entry
+		callee, calleeStart := p.callee(i)
+		if !cfg.Callees.Match(callee) {
+			continue
+		}
+		// t() without arguments
+		if p.isPunct(i+2, ")") {
+			continue
+		}
+		callPos := p.tok(calleeStart).Pos
+		if !p.isPunct(i+2, "{") {
+			arg, _ := p.parseValue(i + 2)
+			diags.add(RuleNonLiteral, callPos, "%s() argument is not an object literal: %s", callee, snippet(arg.Raw, 100))
+			continue
+		}
+		obj, next := p.parseValue(i + 2)
+		if obj.Kind != ValueObject {
+			diags.add(RuleInvalidLiteral, callPos, "%s({ could not be parsed as an object literal: %s", callee, snippet(obj.Raw, 100))
+			continue
+		}
+		// Continue after the parsed object, nested calls in it are not translation calls
+		i = next - 1
+
+		entry, problems := entryFromObject(obj)
+		for _, problem := range problems {
+			diags.add(RuleNonLiteral, callPos, "%s", problem)
+		}
+		if entry == nil {
+			continue
+		}
+		if entry.Code == "" && !cfg.GenerateCodes {
+			diags.add(RuleMissingCode, callPos, "%s() call has no code", callee)
+			continue
+		}
+		if !entry.hasMessage() {
+			if entry.Code == "" {
+				diags.add(RuleMissingMessage, callPos, "%s() call has no code, no msg and no non-empty msgs", callee)
+			} else {
+				diags.add(RuleMissingMessage, callPos, "code %q has no msg and no non-empty msgs", entry.Code)
+			}
+			continue
+		}
+		if entry.Code == "" {
+			// hasMessage implies a msg or msgs property
+			entry.CodePos = obj.Props[0].Pos
+		}
+		repl := p.parseReplacements(next)
+		checkPlaceholders(diags, callPos, *entry, repl)
+		checkPlural(diags, callPos, *entry, repl)
+		// computed keys can not be named in the types file, see renderTypes
+		if keys := countKeys(repl.value); len(entry.Msgs) != 0 && repl.known && !inSlice(keys, "") {
+			entry.CountKeys = keys
+		}
+		naming.check(diags, callPos, entry.Code)
+		lineNum, col := lines.Position(callPos)
 		entry.Location = fmt.Sprintf("%v:%v", file, lineNum)
-		entry.Code = entryJSON.Code
-		entry.Desc = entryJSON.Desc
-		entry.Msg = normalizeString(entryJSON.Msg)
-		entry.Msgs = map[string]string{}
-		for k, v := range entryJSON.Msgs {
-			entry.Msgs[k] = normalizeString(v)
+		entry.Column = col
+		entry.Callee = callee
+		entry.Scope = scopes[calleeStart]
+		entry.Note = notes.before(callPos)
+		res = append(res, *entry)
+	}
+
+	r := Result{Entries: res, Diagnostics: diags.list}
+	if cfg.TSCompat {
+		r.TSEntries = ExtractTS(file, data)
+	}
+	return r
+}
+
+// CodeLiteral is the string literal of the code of a translation call.
+type CodeLiteral struct {
+	Code string
+	// Pos and End are the byte offsets of the literal, including the quotes
+	Pos, End int
+}
+
+// CodeLiterals returns the code literals of the translation calls of a file matching cfg.Callees, for rewriting codes. Calls are found the same way as by Extract, calls without a literal code are skipped.
+func CodeLiterals(file string, data []byte, cfg Config) []CodeLiteral {
+	code := data
+	if IsMDXFile(file) {
+		code = MDXCode(data)
+	}
+	p := newParser(code, Tokenize(code, IsJSXFile(file)))
+	var res []CodeLiteral
+	for i := 0; i < len(p.toks); i++ {
+		if !p.isCall(i) || !p.isPunct(i+2, "{") {
+			continue
+		}
+		if callee, _ := p.callee(i); !cfg.Callees.Match(callee) {
+			continue
 		}
-		if len(entry.Msgs) == 0 {
-			entry.Msgs = nil
+		obj, next := p.parseValue(i + 2)
+		if obj.Kind != ValueObject {
+			continue
+		}
+		i = next - 1
+		if v, found := obj.Get("code"); found && v.Kind == ValueString {
+			res = append(res, CodeLiteral{Code: v.Str, Pos: v.Pos, End: v.End})
 		}
-		res = append(res, entry)
-		// Advance start to after the parsed JSON object
-		ind += offset + int(dec.InputOffset())
 	}
+	return res
+}
 
-	return
+// hasMessage reports whether the entry has a message to translate, either msg or at least one non-empty plural form.
+func (e Entry) hasMessage() bool {
+	if e.Msg != "" {
+		return true
+	}
+	for _, msg := range e.Msgs {
+		if msg != "" {
+			return true
+		}
+	}
+	return false
 }
 
-// Accepts string or []any where each element is a string, if array passed returns joined using newline. This is to support multiline strings for translations without having to put them all into one line separating with \n.
-func normalizeString(v any) string {
-	if v == nil {
-		return ""
+// isCall reports whether token i is the identifier of a function call. Declarations such as function t( are not calls.
+func (p *parser) isCall(i int) bool {
+	t := p.tok(i)
+	if t.Kind != TokenIdent || !p.isPunct(i+1, "(") {
+		return false
 	}
-	switch v := v.(type) {
-	case string:
-		return v
-	case []any:
-		var parts []string
-		for _, item := range v {
-			str, ok := item.(string)
-			if !ok {
-				panic("all elements in the array must be strings")
+	if i > 0 {
+		prev := p.tok(i - 1)
+		if prev.Kind == TokenIdent && prev.Text == "function" {
+			return false
+		}
+	}
+	return true
+}
+
+// entryFromObject reads the entry from the first argument of a translation call. It returns nil if the code or message are not literals, problems lists everything that could not be read.
+func entryFromObject(obj Value) (entry *Entry, problems []string) {
+	var e Entry
+	ok := true
+	if obj.HasSpread() {
+		problems = append(problems, fmt.Sprintf("object has spread or computed properties: %s", snippet(obj.Raw, 100)))
+		ok = false
+	}
+	if v, found := obj.Get("code"); found {
+		if v.Kind != ValueString {
+			problems = append(problems, fmt.Sprintf("code is not a string literal: %s", snippet(v.Raw, 100)))
+			ok = false
+		}
+		e.Code = v.Str
+	}
+	if v, found := obj.Get("desc"); found {
+		// the entry is still usable without the description
+		if v.Kind != ValueString {
+			problems = append(problems, fmt.Sprintf("desc is not a string literal: %s", snippet(v.Raw, 100)))
+		}
+		e.Desc = v.Str
+	}
+	if v, found := obj.Get("context"); found {
+		if v.Kind != ValueString {
+			problems = append(problems, fmt.Sprintf("context is not a string literal: %s", snippet(v.Raw, 100)))
+			ok = false
+		}
+		e.Context = v.Str
+	}
+	if v, found := obj.Get("msg"); found {
+		msg, err := normalizeString(v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("msg %v", err))
+			ok = false
+		}
+		e.Msg = msg
+	}
+	if v, found := obj.Get("msgs"); found {
+		if v.Kind != ValueObject || v.HasSpread() {
+			problems = append(problems, fmt.Sprintf("msgs is not an object literal: %s", snippet(v.Raw, 100)))
+			ok = false
+		} else {
+			e.Msgs = map[string]string{}
+			for _, prop := range v.Props {
+				msg, err := normalizeString(prop.Value)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("msgs.%v %v", prop.Key, err))
+					ok = false
+				}
+				if _, ok := e.Msgs[prop.Key]; !ok {
+					e.Forms = append(e.Forms, prop.Key)
+				}
+				e.Msgs[prop.Key] = msg
+			}
+			if len(e.Msgs) == 0 {
+				e.Msgs = nil
 			}
-			parts = append(parts, str)
 		}
-		return strings.Join(parts, "\n")
-	default:
-		panic("input must be a string or []any")
 	}
+	if !ok {
+		return nil, problems
+	}
+	return &e, problems
 }
 
-func isBoundary(b byte) bool {
-	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '.' || b == '{'
+// Accepts string or array of strings, if array passed returns joined using newline. This is to support multiline strings for translations without having to put them all into one line separating with \n.
+func normalizeString(v Value) (string, error) {
+	switch v.Kind {
+	case ValueString:
+		return v.Str, nil
+	case ValueArray:
+		var parts []string
+		for _, item := range v.Items {
+			if item.Kind != ValueString {
+				return "", fmt.Errorf("array elements must be string literals, got %v", snippet(item.Raw, 100))
+			}
+			parts = append(parts, item.Str)
+		}
+		return strings.Join(parts, "\n"), nil
+	default:
+		return "", fmt.Errorf("is not a string literal or an array of string literals: %v", snippet(v.Raw, 100))
+	}
 }
 
-func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
+// snippet shortens source code for messages to maxLen runes, collapsing whitespace and newlines. It cuts between runes, so messages stay valid UTF-8.
+func snippet(s string, maxLen int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= maxLen {
 		return s
 	}
-	return s[:maxLen] + "..."
+	return string(runes[:maxLen]) + "..."
 }
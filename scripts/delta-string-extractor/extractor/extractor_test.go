@@ -2,9 +2,11 @@ package extractor
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestDecoderExample(t *testing.T) {
@@ -46,6 +48,9 @@ function test(){
 			Code:     "dialog.hello",
 			Desc:     "Hello message",
 			Msg:      "Hello {name}",
+			Callee:   "t",
+			Column:   10,
+			Scope:    "test",
 		},
 	}
 
@@ -76,16 +81,19 @@ function test(){
 			Location: "f.js:3",
 			Code:     "dialog.hello",
 			Desc:     "Hello message",
-			Msg:      "Hello {"},
+			Msg:      "Hello {",
+			Callee:   "t",
+			Column:   10,
+			Scope:    "test"},
 	}
 
-	res, err := ExtractFromContent(testFile, []byte(in))
-	if err != nil {
-		panic(err)
-	}
+	res := Extract(testFile, []byte(in), DefaultConfig())
 
-	if !reflect.DeepEqual(want, res) {
-		t.Errorf("wanted %v, got %v", want, res)
+	if !reflect.DeepEqual(want, res.Entries) {
+		t.Errorf("wanted %v, got %v", want, res.Entries)
+	}
+	if len(res.Diagnostics) == 0 || res.Diagnostics[0].Rule != RuleUnbalancedBraces {
+		t.Errorf("wanted unbalanced braces error, got %v", res.Diagnostics)
 	}
 }
 
@@ -125,6 +133,9 @@ function test(){
 			Code:     "dialog.hello",
 			Desc:     "Hello message",
 			Msg:      "Hello {name}",
+			Callee:   "t",
+			Column:   10,
+			Scope:    "test",
 		},
 	}
 
@@ -137,3 +148,694 @@ function test(){
 		t.Errorf("wanted %v, got %v", want, res)
 	}
 }
+
+func TestExtractJSObjectLiteral(t *testing.T) {
+	in := `
+export function View(ctx: ViewContext) {
+	return ctx.t({ code: "common.expand_all", msg: "Expand All" });
+}
+const a = backendCtx.t({
+	'code': 'dialog.quoted',
+	// comment between properties
+	desc: ` + "`Template desc`" + `,
+	msg: [
+		"Line one",
+		/* inline comment */ 'Line two',
+	],
+}, { name: "x" });
+`
+	want := []Entry{
+		{
+			Location: "f.js:3",
+			Code:     "common.expand_all",
+			Msg:      "Expand All",
+			Callee:   "ctx.t",
+			Column:   9,
+			Scope:    "View",
+		},
+		{
+			Location: "f.js:5",
+			Code:     "dialog.quoted",
+			Desc:     "Template desc",
+			Msg:      "Line one\nLine two",
+			Callee:   "backendCtx.t",
+			Column:   11,
+			Scope:    "a",
+		},
+	}
+
+	res, err := ExtractFromContent(testFile, []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(want, res) {
+		t.Errorf("wanted %v, got %v", want, res)
+	}
+}
+
+func TestExtractPlural(t *testing.T) {
+	in := `ctx.t({
+	code: "items.count",
+	msgs: {
+		one: "{n} item",
+		other: "{n} items",
+	},
+}, { n: 2 })`
+
+	want := []Entry{
+		{
+			Location:  "f.js:1",
+			Code:      "items.count",
+			Msgs:      map[string]string{"one": "{n} item", "other": "{n} items"},
+			Forms:     []string{"one", "other"},
+			Callee:    "ctx.t",
+			Column:    1,
+			CountKeys: []string{"n"},
+		},
+	}
+
+	res, err := ExtractFromContent(testFile, []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(want, res) {
+		t.Errorf("wanted %v, got %v", want, res)
+	}
+}
+
+func TestExtractEscapes(t *testing.T) {
+	in := `ctx.t({ code: "a", msg: 'It\'s é "quoted"\n' })`
+
+	res, err := ExtractFromContent(testFile, []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "It's é \"quoted\"\n"
+	if len(res) != 1 || res[0].Msg != want {
+		t.Errorf("wanted %q, got %v", want, res)
+	}
+}
+
+func TestExtractSkipsStringsCommentsRegexes(t *testing.T) {
+	in := `
+const s = "ctx.t({ code: 'in.string', msg: 'x' })";
+const s2 = ` + "`ctx.t({ code: 'in.template' })`" + `;
+// ctx.t({ code: "in.line.comment", msg: "x" })
+/* ctx.t({ code: "in.block.comment", msg: "x" }) */
+const re = /t\({/g;
+const re2 = x.replace(/"/g, "'");
+function t(params) { return params }
+ctx.t({ code: "real", msg: "Real" });
+`
+	res, err := ExtractFromContent(testFile, []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Code != "real" || res[0].Location != "f.js:9" {
+		t.Errorf("wanted only the real call, got %v", res)
+	}
+}
+
+func TestExtractJSX(t *testing.T) {
+	in := `
+export default function Page() {
+	return (
+		<div title="Don't" className='a'>
+			Don't worry, it's fine
+			{ctx.t({ code: "page.title", msg: "Title" })}
+			<Comp render={() => <b>{ctx.t({ code: "page.nested", msg: "Nested" })}</b>} />
+			{/* {ctx.t({ code: "page.commented", msg: "x" })} */}
+		</div>
+	);
+}
+const x = a < b ? 1 : 2;
+const f = <T,>(v: T) => ctx.t({ code: "page.after", msg: "After" });
+`
+	res, err := ExtractFromContent("f.tsx", []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []string
+	for _, e := range res {
+		codes = append(codes, e.Code)
+	}
+	want := []string{"page.title", "page.nested", "page.after"}
+	if !reflect.DeepEqual(want, codes) {
+		t.Errorf("wanted %v, got %v", want, codes)
+	}
+}
+
+func TestExtractNonLiteral(t *testing.T) {
+	in := `ctx.t({ code: someVar, msg: "x" })`
+	_, err := ExtractFromContent(testFile, []byte(in))
+	if err == nil {
+		t.Errorf("expected error for non-literal code")
+	}
+}
+
+func TestExtractNonLiteralDiagnostics(t *testing.T) {
+	in := `
+ctx.t({ code: someVar, msg: "x" });
+  ctx.t({ code: "tpl", msg: ` + "`Hello ${name}`" + ` });
+ctx.t({ code: "ok", msg: "Ok" });
+ctx.t({ code, msg });
+ctx.t(params);
+ctx.t({ code: "plural", msgs: { one: "a", other: other } });
+ctx.t({ ...base, code: "spread", msg: "x" });
+ctx.t({ code: "pre" + fix, msg: "x" });
+ctx.t();
+`
+	res := Extract(testFile, []byte(in), DefaultConfig())
+	if len(res.Entries) != 1 || res.Entries[0].Code != "ok" {
+		t.Errorf("wanted only the literal entry, got %v", res.Entries)
+	}
+	var got []string
+	for _, d := range res.Diagnostics {
+		got = append(got, fmt.Sprintf("%d:%d %s %s", d.Line, d.Column, d.Rule, d.Severity))
+	}
+	want := []string{
+		"2:1 non-literal error",
+		"3:3 non-literal error",
+		"5:1 non-literal error",
+		"5:1 non-literal error",
+		"6:1 non-literal error",
+		"7:1 non-literal error",
+		"8:1 non-literal error",
+		"9:1 non-literal error",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Severities = map[string]Severity{RuleNonLiteral: SeverityWarning}
+	res = Extract(testFile, []byte(in), cfg)
+	if HasErrors(res.Diagnostics) || len(res.Diagnostics) != len(want) {
+		t.Errorf("wanted only warnings, got %v", res.Diagnostics)
+	}
+
+	cfg.Severities = map[string]Severity{RuleNonLiteral: SeverityOff}
+	res = Extract(testFile, []byte(in), cfg)
+	if len(res.Diagnostics) != 0 {
+		t.Errorf("wanted no diagnostics, got %v", res.Diagnostics)
+	}
+}
+
+func TestExtractCallees(t *testing.T) {
+	in := `
+ctx.t({ code: "a", msg: "A" });
+BackendContext.t({ code: "b", msg: "B" });
+props?.ctx?.t({ code: "c", msg: "C" });
+getCtx().t({ code: "d", msg: "D" });
+t({ code: "e", msg: "E" });
+i18n.translate({ code: "f", msg: "F" });
+`
+	tests := []struct {
+		name    string
+		matcher CalleeMatcher
+		want    []string
+	}{
+		{"default", DefaultConfig().Callees, []string{"ctx.t", "BackendContext.t", "props.ctx.t", "getCtx().t", "t"}},
+		{"exact", CalleeMatcher{Include: []string{"ctx.t"}}, []string{"ctx.t"}},
+		{"exclude", CalleeMatcher{Include: []string{"*.t"}, Exclude: []string{"BackendContext.t", "*().t"}}, []string{"ctx.t", "props.ctx.t"}},
+		{"other name", CalleeMatcher{Include: []string{"i18n.translate"}}, []string{"i18n.translate"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := Extract(testFile, []byte(in), Config{Callees: tc.matcher})
+			if len(res.Diagnostics) != 0 {
+				t.Fatal(res.Diagnostics)
+			}
+			var callees []string
+			for _, e := range res.Entries {
+				callees = append(callees, e.Callee)
+			}
+			if !reflect.DeepEqual(tc.want, callees) {
+				t.Errorf("wanted %v, got %v", tc.want, callees)
+			}
+		})
+	}
+}
+
+func TestLineIndex(t *testing.T) {
+	data := []byte("ab\nсd\n\nx")
+	li := NewLineIndex(data)
+	tests := []struct{ offset, line, col int }{
+		{0, 1, 1},
+		{2, 1, 3},
+		{3, 2, 1},
+		{5, 2, 2}, // after the two byte с
+		{7, 3, 1},
+		{8, 4, 1},
+	}
+	for _, tc := range tests {
+		line, col := li.Position(tc.offset)
+		if line != tc.line || col != tc.col {
+			t.Errorf("offset %d: wanted %d:%d, got %d:%d", tc.offset, tc.line, tc.col, line, col)
+		}
+	}
+}
+
+func TestExtractPlaceholders(t *testing.T) {
+	in := `
+ctx.t({ code: "a", msg: "Hello {name}" }, { name: user.name });
+ctx.t({ code: "b", msg: "Hello {name}" });
+ctx.t({ code: "c", msg: "Hello {name}" }, { nmae: "x" });
+ctx.t({ code: "d", msg: "Hello" }, { name: "x" });
+ctx.t({ code: "e", msg: "Hello {name}" }, replacements);
+ctx.t({ code: "f", msgs: { one: "{n} item", other: "{n} items" } }, { n: 1 });
+ctx.t({ code: "g", msgs: { one: "One item", other: "Many items" } }, { count });
+ctx.t({ code: "h", msg: "Hello } {" }, {});
+ctx.t({ code: "i", msg: "{a} and {b}" }, { a, ...rest });
+`
+	res := Extract(testFile, []byte(in), DefaultConfig())
+	var got []string
+	for _, d := range res.Diagnostics {
+		got = append(got, fmt.Sprintf("%d %s", d.Line, d.Rule))
+	}
+	want := []string{
+		"3 placeholder-missing",
+		"4 placeholder-missing",
+		"4 placeholder-unused",
+		"5 placeholder-unused",
+		"9 unbalanced-braces",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestExtractPlurals(t *testing.T) {
+	in := `
+ctx.t({ code: "a", msgs: { one: "{n} item", other: "{n} items" } }, { n: 1 });
+ctx.t({ code: "b", msgs: { one: "{n} item", many: "{n} items" } }, { n: 1 });
+ctx.t({ code: "c", msgs: { one: "{n} item", plural: "{n} items", other: "{n} items" } }, { n: 1 });
+ctx.t({ code: "d", msgs: { one: "One item", other: "Items" } });
+ctx.t({ code: "e", msgs: { one: "{n} item", other: "{n} items" } }, { n: "1" });
+ctx.t({ code: "f", msgs: { one: "{n} item", other: "{n} items" } }, { n: 1.5 });
+ctx.t({ code: "g", msgs: { one: "{n} item", other: "{n} items" } }, { n: items.length });
+ctx.t({ code: "h", msgs: { one: "{n} item", other: "{n} items" } }, params);
+`
+	res := Extract(testFile, []byte(in), DefaultConfig())
+	var got []string
+	for _, d := range res.Diagnostics {
+		if d.Rule == RuleBadPlural {
+			got = append(got, fmt.Sprintf("%d %s", d.Line, d.Message))
+		}
+	}
+	want := []string{
+		"3 msgs has no \"other\" form, it is the fallback for all languages",
+		"4 msgs has keys that are not CLDR plural categories (zero, one, two, few, many, other): plural",
+		"5 plural message without a number in the replacements, it is needed to pick the form",
+		"6 plural message without a number in the replacements, it is needed to pick the form",
+		"7 plural message without a number in the replacements, it is needed to pick the form",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestExtractCountKeys(t *testing.T) {
+	in := `
+ctx.t({ code: "a", msgs: { one: "{user} has {n} item", other: "{user} has {n} items" } }, { user: "Ann", n: 2 });
+ctx.t({ code: "b", msgs: { one: "One item", other: "Items" } }, { user: name, count: items.length });
+ctx.t({ code: "c", msgs: { one: "{n} item", other: "{n} items" } }, { [key]: 2 });
+ctx.t({ code: "d", msgs: { one: "{n} item", other: "{n} items" } }, params);
+ctx.t({ code: "e", msg: "{n} items" }, { n: 2 });
+`
+	res := Extract(testFile, []byte(in), DefaultConfig())
+	got := map[string][]string{}
+	for _, e := range res.Entries {
+		got[e.Code] = e.CountKeys
+	}
+	// computed keys and replacements that are not object literals are not known
+	want := map[string][]string{"a": {"n"}, "b": {"user", "count"}, "c": nil, "d": nil, "e": nil}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestExtractNaming(t *testing.T) {
+	in := `
+ctx.t({ code: "admin.add_user", msg: "Add" });
+ctx.t({ code: "Admin.AddUser", msg: "Add" });
+ctx.t({ code: "admin.users.list.title.short", msg: "Users" });
+ctx.t({ code: "misc.hello", msg: "Hello" });
+ctx.t({ code: "common.save", msg: "Save" });
+ctx.t({ code: "user_login.title", msg: "Login" });
+ctx.t({ code: "analysis.title", msg: "Analysis" });
+`
+	cfg := DefaultConfig()
+	cfg.Naming = NamingRules{
+		Pattern:          `^[a-z0-9_]+(\.[a-z0-9_]+)*$`,
+		MaxDepth:         4,
+		Namespaces:       []string{"admin", "common", "user_login", "analysis"},
+		MatchDirectory:   true,
+		SharedNamespaces: []string{"common"},
+	}
+	res := Extract("routes/$lang+/admin+/user+/login.tsx", []byte(in), cfg)
+	var got []string
+	for _, d := range res.Diagnostics {
+		got = append(got, fmt.Sprintf("%d %s", d.Line, d.Rule))
+	}
+	want := []string{
+		"3 code-format",
+		"3 code-namespace",
+		"4 code-format",
+		"5 code-namespace",
+		"5 code-directory",
+		"8 code-directory",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestCheckNearDuplicates(t *testing.T) {
+	entries := []Entry{
+		{Location: "a.tsx:1", Column: 5, Code: "common.expand_all"},
+		{Location: "b.tsx:2", Column: 3, Code: "common.expandAll"},
+		{Location: "c.tsx:3", Column: 1, Code: "common.expand_all"},
+		{Location: "c.tsx:4", Column: 1, Code: "common.collapse_all"},
+	}
+	cfg := DefaultConfig()
+	if diags := CheckNearDuplicates(entries, cfg); len(diags) != 0 {
+		t.Errorf("wanted no diagnostics when disabled, got %v", diags)
+	}
+	cfg.Naming.NearDuplicates = true
+	var got []string
+	for _, d := range CheckNearDuplicates(entries, cfg) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		`a.tsx:1:5: error: code "common.expand_all" only differs by case or separators from common.expandAll [code-near-duplicate]`,
+		`b.tsx:2:3: error: code "common.expandAll" only differs by case or separators from common.expand_all [code-near-duplicate]`,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestExtractTranslatorComments(t *testing.T) {
+	in := `
+// i18n: Button that deletes
+// the selected record
+ctx.t({ code: "a", msg: "Delete" });
+
+/* translators: verb, not the noun */
+ctx.t({ code: "b", msg: "Record", desc: "Record button." });
+
+// eslint-disable-next-line
+// i18n: only this part
+ctx.t({ code: "c", msg: "Close" });
+
+// i18n: too far away
+
+ctx.t({ code: "d", msg: "Open" });
+
+// a normal comment
+ctx.t({ code: "e", msg: "Save" });
+
+/**
+ * Translators: shown in
+ * the page header
+ */
+const title = ctx.t({ code: "f", msg: "Title" });
+`
+	res := Extract(testFile, []byte(in), DefaultConfig())
+	got := map[string]string{}
+	for _, e := range res.Entries {
+		got[e.Code] = e.Description()
+	}
+	want := map[string]string{
+		"a": "Button that deletes the selected record",
+		"b": "Record button. verb, not the noun",
+		"c": "only this part",
+		"d": "",
+		"e": "",
+		"f": "shown in the page header",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestExtractContext(t *testing.T) {
+	in := `
+ctx.t({ code: "common.close", msg: "Close", context: "verb, closes the dialog" });
+ctx.t({ code: "common.near", msg: "Close", context: kind });
+`
+	res := Extract(testFile, []byte(in), DefaultConfig())
+	if len(res.Entries) != 1 || res.Entries[0].Context != "verb, closes the dialog" {
+		t.Errorf("wanted one entry with context, got %+v", res.Entries)
+	}
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Rule != RuleNonLiteral || res.Diagnostics[0].Line != 3 {
+		t.Errorf("wanted a non-literal diagnostic on line 3, got %v", res.Diagnostics)
+	}
+}
+
+func TestExtractScopes(t *testing.T) {
+	in := `
+import { x } from "y";
+const title = ctx.t({ code: "a", msg: "A" });
+export const loader = authLoader(async ({ request }) => {
+	return { title: backendCtx.t({ code: "b", msg: "B" }) };
+});
+function helper() {
+	return [1].map(function inner() { return ctx.t({ code: "c", msg: "C" }) });
+}
+ctx.t({ code: "d", msg: "D" });
+export default function Screen() {
+	return <div title={ctx.t({ code: "e", msg: "E" })}>{ctx.t({ code: "f", msg: "F" })}</div>;
+}
+export default () => ctx.t({ code: "g", msg: "G" });
+`
+	res := Extract("f.tsx", []byte(in), DefaultConfig())
+	got := map[string]string{}
+	for _, e := range res.Entries {
+		got[e.Code] = e.Scope
+	}
+	want := map[string]string{"a": "title", "b": "loader", "c": "helper", "d": "", "e": "Screen", "f": "Screen", "g": "default"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestExtractMissingCodeOrMessage(t *testing.T) {
+	in := `
+ctx.t({ msg: "No code" });
+ctx.t({ code: "a" });
+ctx.t({ code: "b", msgs: { one: "", other: "" } });
+ctx.t({ code: "c", msgs: { one: "", other: "Items" } });
+`
+	res := Extract(testFile, []byte(in), DefaultConfig())
+	var got []string
+	for _, d := range res.Diagnostics {
+		if d.Rule == RuleMissingCode || d.Rule == RuleMissingMessage {
+			got = append(got, fmt.Sprintf("%d %s", d.Line, d.Rule))
+		}
+	}
+	want := []string{"2 missing-code", "3 missing-msg", "4 missing-msg"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+	if len(res.Entries) != 1 || res.Entries[0].Code != "c" {
+		t.Errorf("wanted only entry c, got %v", res.Entries)
+	}
+}
+
+func TestCodeLiterals(t *testing.T) {
+	in := `
+ctx.t({ code: "a", msg: "A" });
+ctx.t({ code: 'b', msg: "B", x: t({ code: "nested", msg: "N" }) });
+ctx.t({ msg: "No code" });
+ctx.t({ code: name, msg: "Dynamic" });
+other({ code: "c", msg: "C" });
+`
+	var got []string
+	for _, lit := range CodeLiterals(testFile, []byte(in), DefaultConfig()) {
+		got = append(got, lit.Code+" "+in[lit.Pos:lit.End])
+	}
+	want := []string{`a "a"`, `b 'b'`}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestExtractGenerateCodes(t *testing.T) {
+	in := `
+ctx.t({ msg: "No code" });
+ctx.t({});
+ctx.t({ code: "a", msg: "A" });
+`
+	cfg := DefaultConfig()
+	cfg.GenerateCodes = true
+	res := Extract(testFile, []byte(in), cfg)
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Rule != RuleMissingMessage || res.Diagnostics[0].Line != 3 {
+		t.Errorf("wanted only missing-msg on line 3, got %v", res.Diagnostics)
+	}
+	if len(res.Entries) != 2 {
+		t.Fatalf("wanted 2 entries, got %v", res.Entries)
+	}
+	if e := res.Entries[0]; e.Code != "" || e.Msg != "No code" || e.CodePos != strings.Index(in, "msg") {
+		t.Errorf("wanted entry without code before msg, got %+v", e)
+	}
+	if e := res.Entries[1]; e.CodePos != 0 {
+		t.Errorf("wanted no CodePos for an entry with a code, got %+v", e)
+	}
+}
+
+func TestExtractMDX(t *testing.T) {
+	in := "import { Note } from \"./note\";\n" +
+		"\n" +
+		"# Don't use t( in text, it's markdown\n" +
+		"\n" +
+		"Intro {ctx.t({ code: \"docs.intro\", msg: \"Intro\" })} and `ctx.t({ code: \"docs.span\" })`.\n" +
+		"\n" +
+		"```js\n" +
+		"ctx.t({ code: \"docs.example\", msg: \"Example\" })\n" +
+		"```\n" +
+		"\n" +
+		"<Note title={ctx.t({\n" +
+		"  code: \"docs.note\",\n" +
+		"  msg: \"Note\",\n" +
+		"})} />\n"
+	res := Extract("guide.mdx", []byte(in), DefaultConfig())
+	var got []string
+	for _, e := range res.Entries {
+		got = append(got, fmt.Sprintf("%s %s:%d", e.Code, e.Location, e.Column))
+	}
+	want := []string{"docs.intro guide.mdx:5:8", "docs.note guide.mdx:11:14"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+	if len(res.Diagnostics) != 0 {
+		t.Errorf("wanted no diagnostics, got %v", res.Diagnostics)
+	}
+}
+
+func TestFindHardcoded(t *testing.T) {
+	in := `export function Page({ ctx }) {
+	const title = "Not JSX";
+	return (
+		<Form title="Settings" className="wide">
+			<h1>{ctx.t({ code: "a", msg: "Translated" })}</h1>
+			<p>
+				Hello   world
+			</p>
+			<input placeholder={"Name"} aria-label={` + "`Your name`" + `} value="x" />
+			<img alt={logoAlt} src="/logo.png" />
+			<span>{count} / 100 &nbsp;</span>
+			{/* i18n-ignore */}
+			<code>ctx.t</code>
+			<b>DELTA</b>
+		</Form>
+	);
+}
+`
+	cfg := DefaultConfig()
+	cfg.Hardcoded.Allow = []string{"^DELTA$"}
+	var got []string
+	for _, d := range FindHardcoded("page.tsx", []byte(in), cfg) {
+		got = append(got, fmt.Sprintf("%d:%d %s", d.Line, d.Column, d.Message))
+	}
+	want := []string{
+		`4:15 attribute title "Settings" is not translated`,
+		`7:5 JSX text "Hello world" is not translated`,
+		`9:23 attribute placeholder "Name" is not translated`,
+		`9:43 attribute aria-label "Your name" is not translated`,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %q, got %q", want, got)
+	}
+
+	// the source ranges to replace with a translation call
+	got = nil
+	for _, s := range HardcodedStrings("page.tsx", []byte(in), cfg) {
+		got = append(got, in[s.Pos:s.End])
+	}
+	want = []string{`"Settings"`, "Hello   world", `{"Name"}`, "{`Your name`}"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %q, got %q", want, got)
+	}
+}
+
+func TestHardcodedStringsRuns(t *testing.T) {
+	in := `export function Page({ user, items }) {
+	return (
+		<div>
+			<p>Hello {user.name}, you have {items.length} items{" "}<b>new</b></p>
+			<p>
+				{user.name} and {other.name}, {user.name}
+			</p>
+			<p>Total {items.length > 1 ? "items" : "item"} here</p>
+			<p>Count {count}{/* note */}</p>
+		</div>
+	);
+}
+`
+	var got []string
+	for _, s := range HardcodedStrings("page.tsx", []byte(in), DefaultConfig()) {
+		got = append(got, fmt.Sprintf("%q %q %v %v", s.Text, in[s.Pos:s.End], s.Replacements, s.Mixed))
+	}
+	want := []string{
+		`"Hello {name}, you have {length} items" "Hello {user.name}, you have {items.length} items" [{name user.name} {length items.length}] false`,
+		`"new" "new" [] false`,
+		`"{name} and {name2}, {name}" "{user.name} and {other.name}, {user.name}" [{name user.name} {name2 other.name}] false`,
+		`"Total" "Total" [] true`,
+		`"here" "here" [] true`,
+		`"Count {count}" "Count {count}" [{count count}] false`,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestExtractTS(t *testing.T) {
+	in := `
+ctx.t({ code: "a", msg: "First", msg: "Second", desc: "Shown" });
+ctx.t({ code: "b", msg: ` + "`Template`" + `, msgs: { other: "{n} items", one: "{n} item", "2": "two", 3: "number", "1": "x", "1": "{n} one", bad: ` + "`x`" + ` } });
+ctx.t({ code: ` + "`c`" + `, msg: "Template code" });
+ctx.t({ code: "d", msg: ["Line 1", "Line 2"], desc: ` + "`no`" + ` });
+ctx.t({ code: "e", msg: outer(backendCtx.t({ code: "f", msg: "Nested" })) });
+ctx.t({ "code": "g", msg: ["Line", ` + "`x`" + `], msgs: {} });
+t({ code: "h", msg: "Plain call" });
+new ctx.t({ code: "i", msg: "Constructor" });
+ctx.t({ code, msg: "Shorthand" });
+`
+	var got []string
+	for _, e := range ExtractTS("f.tsx", []byte(in)) {
+		s := fmt.Sprintf("%s %s %q %q %v %q", e.Location, e.Callee, e.Code, e.Msg, e.Forms, e.Desc)
+		for _, form := range e.Forms {
+			s += " " + form + "=" + e.Msgs[form]
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		`f.tsx:2 ctx.t "a" "First" [] "Shown"`,
+		`f.tsx:3 ctx.t "b" "" [1 2 other one] "" 1={n} one 2=two other={n} items one={n} item`,
+		`f.tsx:5 ctx.t "d" "Line 1\nLine 2" [] ""`,
+		`f.tsx:6 backendCtx.t "f" "Nested" [] ""`,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	if res := ExtractTS("f.js", []byte(in)); res != nil {
+		t.Errorf("wanted no entries for .js files, got %v", res)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	// é and 😀 take several bytes, the cut must not split them
+	if got, want := snippet("ééé\n  😀😀", 4), "ééé ..."; got != want {
+		t.Errorf("wanted %q, got %q", want, got)
+	}
+	if got, want := snippet("😀😀😀", 2), "😀😀..."; got != want || !utf8.ValidString(got) {
+		t.Errorf("wanted %q, got %q", want, got)
+	}
+	if got := snippet("short", 5); got != "short" {
+		t.Errorf("wanted short, got %q", got)
+	}
+}
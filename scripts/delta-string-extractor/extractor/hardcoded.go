@@ -0,0 +1,324 @@
+package extractor
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HardcodedRules select the user-visible literals reported by FindHardcoded.
+type HardcodedRules struct {
+	// Attributes are the JSX attributes holding user-visible text, default DefaultHardcodedAttributes
+	Attributes []string `json:"attributes,omitempty"`
+	// Allow are regular expressions of technical strings that are never reported, matched against the whole text, for example ^DELTA Resilience$
+	Allow []string `json:"allow,omitempty"`
+}
+
+// DefaultHardcodedAttributes are the attributes checked when HardcodedRules.Attributes is empty.
+var DefaultHardcodedAttributes = []string{"placeholder", "title", "aria-label", "alt"}
+
+// Validate checks that the Allow patterns are valid regular expressions.
+func (r HardcodedRules) Validate() error {
+	for _, p := range r.Allow {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("invalid hardcoded allow pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// hardcodedIgnoreMarker in a comment ignores hardcoded strings on the line of the comment and the next line, for example {/* i18n-ignore */} above a JSX element.
+const hardcodedIgnoreMarker = "i18n-ignore"
+
+// htmlEntity matches character references such as &nbsp; and &#8212;, they are not words.
+var htmlEntity = regexp.MustCompile(`&(#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z]+);`)
+
+// HardcodedString is user-visible text in JSX that does not go through a translation call.
+type HardcodedString struct {
+	// Text is the text as written, with whitespace collapsed for JSX text
+	Text string
+	// Attribute is the attribute name, empty for JSX text
+	Attribute string
+	// Pos and End are the byte offsets of the source to replace with an {expression}: the JSX text without surrounding whitespace, the quoted attribute value or the {"value"} of the attribute
+	Pos, End int
+	// Replacements are set for JSX text around expressions that are plain names, such as Hello {user.name}!. The whole run of text and expressions is one string then, with a placeholder for each expression.
+	Replacements []Replacement
+	// Mixed is set for JSX text next to an expression that is not a plain name, such as {count} {count > 1 ? "items" : "item"}, it can not be turned into one message automatically
+	Mixed bool
+}
+
+// Replacement is a placeholder of a HardcodedString and the source of the expression it stands for.
+type Replacement struct {
+	Name, Expr string
+}
+
+// HardcodedStrings finds user-visible text in JSX that does not go through a translation call: text between tags and string values of cfg.Hardcoded.Attributes, such as <input placeholder="Name" /> or <img alt={"Logo"} />. Text without letters, such as numbers and punctuation, allowed text and text on lines ignored with an i18n-ignore comment are skipped.
+func HardcodedStrings(file string, data []byte, cfg Config) []HardcodedString {
+	code := data
+	if IsMDXFile(file) {
+		// markdown text is translated as a whole document, only the JSX in it is checked
+		code = MDXCode(data)
+	}
+	toks := Tokenize(code, IsJSXFile(file))
+	lines := NewLineIndex(data)
+
+	attrs := cfg.Hardcoded.Attributes
+	if len(attrs) == 0 {
+		attrs = DefaultHardcodedAttributes
+	}
+	var allow []*regexp.Regexp
+	for _, p := range cfg.Hardcoded.Allow {
+		// invalid patterns are rejected by Validate
+		if re, err := regexp.Compile(p); err == nil {
+			allow = append(allow, re)
+		}
+	}
+
+	ignored := make(map[int]bool)
+	for _, t := range toks {
+		if t.Kind == TokenComment && strings.Contains(t.Text, hardcodedIgnoreMarker) {
+			line, _ := lines.Position(t.End)
+			ignored[line] = true
+			ignored[line+1] = true
+		}
+	}
+	var res []HardcodedString
+	add := func(s HardcodedString) {
+		if line, _ := lines.Position(s.Pos); ignored[line] || !hasWords(placeholderRE.ReplaceAllString(s.Text, "")) {
+			return
+		}
+		for _, re := range allow {
+			if re.MatchString(s.Text) {
+				return
+			}
+		}
+		res = append(res, s)
+	}
+
+	containers := jsxContainers(toks)
+	inRun := make(map[int]bool)
+	for i, t := range toks {
+		_, container := containers[i]
+		switch {
+		case (t.Kind == TokenJSXText || container) && !inRun[i]:
+			for _, s := range jsxTextRun(code, toks, i, containers, inRun) {
+				add(s)
+			}
+		case t.Kind == TokenIdent && t.InTag && inSlice(attrs, t.Text):
+			if s, ok := attributeLiteral(toks, i); ok {
+				add(s)
+			}
+		}
+	}
+	return res
+}
+
+// jsxContainers returns the {} expression containers between JSX tags, by the index of their { to the index of their }.
+func jsxContainers(toks []Token) map[int]int {
+	res := make(map[int]int)
+	closing := make(map[int]bool)
+	for i := 1; i < len(toks); i++ {
+		prev := toks[i-1]
+		if !isPunct(toks[i], "{") || toks[i].InTag {
+			continue
+		}
+		// a container follows text, the end of a tag or another container
+		if prev.Kind != TokenJSXText && !(prev.InTag && (isPunct(prev, ">") || isPunct(prev, "/>"))) && !closing[i-1] {
+			continue
+		}
+		depth := 0
+		for j := i; j < len(toks); j++ {
+			if isPunct(toks[j], "{") {
+				depth++
+			} else if isPunct(toks[j], "}") {
+				depth--
+			}
+			if depth == 0 {
+				res[i] = j
+				closing[j] = true
+				break
+			}
+		}
+	}
+	return res
+}
+
+// jsxTextRun returns the hardcoded strings of the run of JSX text and expression containers starting at toks[i] and marks the tokens of the run in inRun. Containers holding nothing but comments end the run.
+//
+// Without expressions every text is a string of its own. If all expressions are plain names, the run is one string with placeholders, see HardcodedString.Replacements, otherwise the texts are returned as Mixed.
+func jsxTextRun(code []byte, toks []Token, i int, containers map[int]int, inRun map[int]bool) []HardcodedString {
+	type item struct {
+		text string
+		pos  int
+		end  int
+		// expr is the source of an expression, placeholder its name if it is a plain name
+		expr, placeholder string
+	}
+	var items []item
+	mixed := false
+	for i < len(toks) {
+		t := toks[i]
+		if t.Kind == TokenJSXText {
+			inRun[i] = true
+			items = append(items, item{text: t.Text, pos: t.Pos, end: t.End})
+			i++
+			continue
+		}
+		end, ok := containers[i]
+		if !ok {
+			break
+		}
+		var expr []Token
+		for _, e := range toks[i+1 : end] {
+			if e.Kind != TokenComment {
+				expr = append(expr, e)
+			}
+		}
+		if len(expr) == 0 {
+			break
+		}
+		inRun[i] = true
+		it := item{pos: t.Pos, end: toks[end].End}
+		switch {
+		case len(expr) == 1 && (expr[0].Kind == TokenString || expr[0].Kind == TokenTemplate):
+			it.text = expr[0].Value
+		default:
+			it.expr = string(code[expr[0].Pos:expr[len(expr)-1].End])
+			it.placeholder = plainName(expr)
+			mixed = mixed || it.placeholder == ""
+		}
+		items = append(items, it)
+		i = end + 1
+	}
+
+	// leading and trailing whitespace is not part of the string
+	for len(items) != 0 && items[0].expr == "" && strings.TrimSpace(items[0].text) == "" {
+		items = items[1:]
+	}
+	for len(items) != 0 && items[len(items)-1].expr == "" && strings.TrimSpace(items[len(items)-1].text) == "" {
+		items = items[:len(items)-1]
+	}
+	hasExpr := false
+	for _, it := range items {
+		hasExpr = hasExpr || it.expr != ""
+	}
+
+	var res []HardcodedString
+	if !hasExpr || mixed {
+		for _, it := range items {
+			if it.expr != "" || code[it.pos] == '{' {
+				continue
+			}
+			if s, ok := jsxText(it.text, it.pos); ok {
+				s.Mixed = mixed
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+
+	var b strings.Builder
+	var repl []Replacement
+	for _, it := range items {
+		if it.expr == "" {
+			b.WriteString(it.text)
+			continue
+		}
+		name := it.placeholder
+		for n := 2; ; n++ {
+			i := slices.IndexFunc(repl, func(r Replacement) bool { return r.Name == name })
+			if i < 0 {
+				repl = append(repl, Replacement{Name: name, Expr: it.expr})
+				break
+			}
+			if repl[i].Expr == it.expr {
+				break
+			}
+			name = fmt.Sprintf("%s%d", it.placeholder, n)
+		}
+		b.WriteString("{" + name + "}")
+	}
+	first, last := items[0], items[len(items)-1]
+	s := HardcodedString{Text: strings.Join(strings.Fields(b.String()), " "), Pos: first.pos, End: last.end, Replacements: repl}
+	if first.expr == "" && code[first.pos] != '{' {
+		s.Pos, _ = jsxTextBounds(first.text, first.pos)
+	}
+	if last.expr == "" && code[last.pos] != '{' {
+		_, s.End = jsxTextBounds(last.text, last.pos)
+	}
+	return append(res, s)
+}
+
+// jsxText returns JSX text starting at pos as a hardcoded string, false if it is only whitespace.
+func jsxText(text string, pos int) (HardcodedString, bool) {
+	collapsed := strings.Join(strings.Fields(text), " ")
+	if collapsed == "" {
+		return HardcodedString{}, false
+	}
+	start, end := jsxTextBounds(text, pos)
+	return HardcodedString{Text: collapsed, Pos: start, End: end}, true
+}
+
+// jsxTextBounds returns the byte offsets of JSX text starting at pos without surrounding whitespace.
+func jsxTextBounds(text string, pos int) (start, end int) {
+	start = pos + strings.IndexFunc(text, func(r rune) bool { return !unicode.IsSpace(r) })
+	last := strings.LastIndexFunc(text, func(r rune) bool { return !unicode.IsSpace(r) })
+	_, size := utf8.DecodeRuneInString(text[last:])
+	return start, pos + last + size
+}
+
+// plainName returns the placeholder name of an expression that is an identifier or a chain of properties, such as user.name, or an empty string for other expressions.
+func plainName(expr []Token) string {
+	for i, t := range expr {
+		if i%2 == 0 && t.Kind != TokenIdent || i%2 == 1 && !isPunct(t, ".") && !isPunct(t, "?.") {
+			return ""
+		}
+	}
+	last := expr[len(expr)-1]
+	if len(expr)%2 == 0 || last.Text == "true" || last.Text == "false" || last.Text == "null" || last.Text == "undefined" {
+		return ""
+	}
+	return last.Text
+}
+
+// FindHardcoded reports the HardcodedStrings of a file as diagnostics.
+func FindHardcoded(file string, data []byte, cfg Config) []Diagnostic {
+	diags := &diagnostics{cfg: cfg, file: file, lines: NewLineIndex(data)}
+	for _, s := range HardcodedStrings(file, data, cfg) {
+		if s.Attribute == "" {
+			diags.add(RuleHardcodedString, s.Pos, "JSX text %q is not translated", s.Text)
+		} else {
+			diags.add(RuleHardcodedString, s.Pos, "attribute %s %q is not translated", s.Attribute, s.Text)
+		}
+	}
+	return diags.list
+}
+
+// attributeLiteral returns the string value of the JSX attribute named by toks[i], either name="value" or name={"value"}.
+func attributeLiteral(toks []Token, i int) (HardcodedString, bool) {
+	if i+2 >= len(toks) || !isPunct(toks[i+1], "=") {
+		return HardcodedString{}, false
+	}
+	name, v := toks[i].Text, toks[i+2]
+	if v.Kind == TokenString && v.InTag {
+		return HardcodedString{Text: v.Value, Attribute: name, Pos: v.Pos, End: v.End}, true
+	}
+	if isPunct(v, "{") && i+4 < len(toks) && isPunct(toks[i+4], "}") {
+		if e := toks[i+3]; e.Kind == TokenString || e.Kind == TokenTemplate {
+			return HardcodedString{Text: e.Value, Attribute: name, Pos: v.Pos, End: toks[i+4].End}, true
+		}
+	}
+	return HardcodedString{}, false
+}
+
+func isPunct(t Token, text string) bool {
+	return t.Kind == TokenPunct && t.Text == text
+}
+
+// hasWords reports whether the text contains a letter outside of character references.
+func hasWords(text string) bool {
+	return strings.IndexFunc(htmlEntity.ReplaceAllString(text, ""), unicode.IsLetter) >= 0
+}
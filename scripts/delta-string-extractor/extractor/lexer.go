@@ -0,0 +1,620 @@
+package extractor
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type TokenKind int
+
+const (
+	TokenEOF TokenKind = iota
+	TokenIdent
+	TokenNumber
+	// TokenString is a single or double quoted string, Value holds the decoded text.
+	TokenString
+	// TokenTemplate is a backtick string without ${} substitutions, Value holds the decoded text.
+	TokenTemplate
+	// TokenTemplateHead, TokenTemplateMiddle and TokenTemplateTail are the parts of a backtick string with substitutions. The tokens of the substituted expressions are emitted between them.
+	TokenTemplateHead
+	TokenTemplateMiddle
+	TokenTemplateTail
+	TokenRegex
+	TokenPunct
+	TokenComment
+	// TokenJSXText is raw text between JSX tags.
+	TokenJSXText
+)
+
+type Token struct {
+	Kind TokenKind
+	// Text is the raw source of the token.
+	Text string
+	// Value is the decoded content for string and template tokens.
+	Value string
+	// Pos and End are byte offsets into the source.
+	Pos int
+	End int
+	// InTag is set for the tokens of a JSX tag, such as the name and the attributes of <input placeholder="Name" />, but not for code in {} inside the tag.
+	InTag bool
+}
+
+type lexModeKind int
+
+const (
+	modeCode lexModeKind = iota
+	modeTemplate
+	modeJSXTag
+	modeJSXChildren
+)
+
+type lexMode struct {
+	kind lexModeKind
+	// depth of unclosed { in modeCode
+	depth int
+	// closing is set for modeJSXTag of </tag>
+	closing bool
+}
+
+type lexer struct {
+	src   string
+	pos   int
+	jsx   bool
+	modes []lexMode
+	toks  []Token
+	// last significant token, used to tell regex from division and JSX from less than
+	last    Token
+	hasLast bool
+	// set right after a JSX element ends in code, which behaves like a closing paren
+	afterJSX bool
+}
+
+// Tokenize splits JS/TS source into tokens, including comments. When jsx is set, JSX elements are recognized (use for .tsx and .jsx files). The lexer never fails, unterminated constructs are recovered from by emitting the opening character as punctuation.
+func Tokenize(src []byte, jsx bool) []Token {
+	l := &lexer{
+		src:   string(src),
+		jsx:   jsx,
+		modes: []lexMode{{kind: modeCode}},
+	}
+	l.run()
+	return l.toks
+}
+
+// IsJSXFile reports whether the file name has an extension that allows JSX syntax. Plain .js files often contain JSX too, TypeScript files other than .tsx do not, there <T> is a type assertion.
+func IsJSXFile(file string) bool {
+	switch path.Ext(file) {
+	case ".tsx", ".jsx", ".js", ".mjs", ".cjs", ".mdx":
+		return true
+	}
+	return false
+}
+
+func (l *lexer) top() *lexMode {
+	return &l.modes[len(l.modes)-1]
+}
+
+func (l *lexer) push(m lexMode) {
+	l.modes = append(l.modes, m)
+}
+
+func (l *lexer) pop() {
+	if len(l.modes) > 1 {
+		l.modes = l.modes[:len(l.modes)-1]
+	}
+}
+
+func (l *lexer) emit(kind TokenKind, start int, value string) {
+	l.toks = append(l.toks, Token{
+		Kind:  kind,
+		Text:  l.src[start:l.pos],
+		Value: value,
+		Pos:   start,
+		End:   l.pos,
+		InTag: l.top().kind == modeJSXTag,
+	})
+	if kind != TokenComment && kind != TokenJSXText {
+		l.last = l.toks[len(l.toks)-1]
+		l.hasLast = true
+		l.afterJSX = false
+	}
+}
+
+func (l *lexer) run() {
+	for l.pos < len(l.src) {
+		switch l.top().kind {
+		case modeCode:
+			l.lexCode()
+		case modeTemplate:
+			// only reached after the closing } of a substitution
+			l.lexTemplate(l.pos, false)
+		case modeJSXTag:
+			l.lexJSXTag()
+		case modeJSXChildren:
+			l.lexJSXChildren()
+		}
+	}
+	l.toks = append(l.toks, Token{Kind: TokenEOF, Pos: len(l.src), End: len(l.src)})
+}
+
+func (l *lexer) peek(off int) byte {
+	if l.pos+off < len(l.src) {
+		return l.src[l.pos+off]
+	}
+	return 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c == '#' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// keywords after which an expression is expected
+var exprKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true,
+	"new": true, "delete": true, "void": true, "throw": true, "case": true,
+	"do": true, "else": true, "yield": true, "await": true,
+}
+
+// exprAllowed reports whether the next token starts an expression, which decides if / starts a regex and if < starts a JSX element.
+func (l *lexer) exprAllowed() bool {
+	if l.afterJSX {
+		return false
+	}
+	if !l.hasLast {
+		return true
+	}
+	switch l.last.Kind {
+	case TokenPunct:
+		switch l.last.Text {
+		case ")", "]", "}", "++", "--":
+			return false
+		}
+		return true
+	case TokenIdent:
+		return exprKeywords[l.last.Text]
+	default:
+		return false
+	}
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.src) && isSpace(l.src[l.pos]) {
+		l.pos++
+	}
+}
+
+// lexComment consumes a comment at the current position if there is one.
+func (l *lexer) lexComment() bool {
+	if l.peek(0) != '/' {
+		return false
+	}
+	start := l.pos
+	switch l.peek(1) {
+	case '/':
+		end := strings.IndexByte(l.src[l.pos:], '\n')
+		if end == -1 {
+			l.pos = len(l.src)
+		} else {
+			l.pos += end
+		}
+	case '*':
+		end := strings.Index(l.src[l.pos+2:], "*/")
+		if end == -1 {
+			l.pos = len(l.src)
+		} else {
+			l.pos += 2 + end + 2
+		}
+	default:
+		return false
+	}
+	l.emit(TokenComment, start, "")
+	return true
+}
+
+var multiPuncts = []string{"...", "=>", "?.", "++", "--"}
+
+func (l *lexer) lexCode() {
+	l.skipSpace()
+	if l.pos >= len(l.src) {
+		return
+	}
+	if l.lexComment() {
+		return
+	}
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case isIdentStart(c):
+		for l.pos < len(l.src) && isIdentPart(l.src[l.pos]) {
+			l.pos++
+		}
+		l.emit(TokenIdent, start, "")
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		l.lexNumber()
+	case c == '"' || c == '\'':
+		l.lexString(c)
+	case c == '`':
+		l.pos++
+		l.lexTemplate(start, true)
+	case c == '/' && l.exprAllowed():
+		l.lexRegex()
+	case c == '<' && l.jsx && l.exprAllowed() && l.looksLikeJSX():
+		l.pos++
+		l.emit(TokenPunct, start, "")
+		l.push(lexMode{kind: modeJSXTag})
+	case c == '{':
+		l.pos++
+		l.top().depth++
+		l.emit(TokenPunct, start, "")
+	case c == '}':
+		l.pos++
+		if l.top().depth > 0 || len(l.modes) == 1 {
+			if l.top().depth > 0 {
+				l.top().depth--
+			}
+			l.emit(TokenPunct, start, "")
+			return
+		}
+		// end of a template substitution or a JSX expression container
+		l.pop()
+		if l.top().kind == modeTemplate {
+			l.lexTemplate(start, false)
+			return
+		}
+		l.emit(TokenPunct, start, "")
+	default:
+		for _, p := range multiPuncts {
+			if strings.HasPrefix(l.src[l.pos:], p) {
+				l.pos += len(p)
+				l.emit(TokenPunct, start, "")
+				return
+			}
+		}
+		_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		l.pos += size
+		l.emit(TokenPunct, start, "")
+	}
+}
+
+func (l *lexer) lexNumber() {
+	start := l.pos
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if isIdentPart(c) || c == '.' {
+			l.pos++
+			continue
+		}
+		// exponent sign
+		if (c == '+' || c == '-') && (l.src[l.pos-1] == 'e' || l.src[l.pos-1] == 'E') && !strings.HasPrefix(l.src[start:], "0x") {
+			l.pos++
+			continue
+		}
+		break
+	}
+	l.emit(TokenNumber, start, "")
+}
+
+// lexString reads a quoted string. Strings can not span lines, so an unterminated string is treated as a lone quote character.
+func (l *lexer) lexString(quote byte) {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\':
+			l.pos += 2
+			continue
+		case c == quote:
+			l.pos++
+			l.emit(TokenString, start, unescape(l.src[start+1:l.pos-1]))
+			return
+		case c == '\n':
+			l.pos = start + 1
+			l.emit(TokenPunct, start, "")
+			return
+		}
+		l.pos++
+	}
+	l.pos = start + 1
+	l.emit(TokenPunct, start, "")
+}
+
+// lexTemplate reads template characters starting at the current position up to the closing backtick or the next substitution. start is the position of the opening backtick or the closing } of the previous substitution.
+func (l *lexer) lexTemplate(start int, opening bool) {
+	if !opening {
+		l.pop()
+	}
+	contentStart := l.pos
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\':
+			l.pos += 2
+			continue
+		case c == '`':
+			content := l.src[contentStart:l.pos]
+			l.pos++
+			if opening {
+				l.emit(TokenTemplate, start, unescape(normalizeNewlines(content)))
+			} else {
+				l.emit(TokenTemplateTail, start, "")
+			}
+			return
+		case c == '$' && l.peek(1) == '{':
+			l.pos += 2
+			if opening {
+				l.emit(TokenTemplateHead, start, "")
+			} else {
+				l.emit(TokenTemplateMiddle, start, "")
+			}
+			l.push(lexMode{kind: modeTemplate})
+			l.push(lexMode{kind: modeCode})
+			return
+		}
+		l.pos++
+	}
+	if l.pos > len(l.src) {
+		l.pos = len(l.src)
+	}
+	// unterminated template, keep whatever was read
+	if opening {
+		l.emit(TokenTemplate, start, unescape(normalizeNewlines(l.src[contentStart:l.pos])))
+	} else {
+		l.emit(TokenTemplateTail, start, "")
+	}
+}
+
+// lexRegex reads a regular expression literal. Like strings, regexes can not span lines.
+func (l *lexer) lexRegex() {
+	start := l.pos
+	l.pos++
+	inClass := false
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\':
+			l.pos += 2
+			continue
+		case c == '\n':
+			l.pos = start + 1
+			l.emit(TokenPunct, start, "")
+			return
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			l.pos++
+			for l.pos < len(l.src) && isIdentPart(l.src[l.pos]) {
+				l.pos++
+			}
+			l.emit(TokenRegex, start, "")
+			return
+		}
+		l.pos++
+	}
+	l.pos = start + 1
+	l.emit(TokenPunct, start, "")
+}
+
+// looksLikeJSX is called with the current position at < in expression position. It rules out generic arrow functions like <T,>(x) => x and <T extends U>(x) => x.
+func (l *lexer) looksLikeJSX() bool {
+	i := l.pos + 1
+	if i >= len(l.src) {
+		return false
+	}
+	if l.src[i] == '>' {
+		return true
+	}
+	if !isIdentStart(l.src[i]) {
+		return false
+	}
+	j := i
+	for j < len(l.src) && isIdentPart(l.src[j]) {
+		j++
+	}
+	k := j
+	for k < len(l.src) && isSpace(l.src[k]) {
+		k++
+	}
+	if k < len(l.src) && l.src[k] == ',' {
+		return false
+	}
+	if k > j && strings.HasPrefix(l.src[k:], "extends ") {
+		return false
+	}
+	return true
+}
+
+func isJSXNamePart(c byte) bool {
+	return isIdentPart(c) || c == '-' || c == '.' || c == ':'
+}
+
+func (l *lexer) lexJSXTag() {
+	l.skipSpace()
+	if l.pos >= len(l.src) {
+		return
+	}
+	if l.lexComment() {
+		return
+	}
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case isIdentStart(c):
+		for l.pos < len(l.src) && isJSXNamePart(l.src[l.pos]) {
+			l.pos++
+		}
+		l.emit(TokenIdent, start, "")
+	case c == '"' || c == '\'':
+		// attribute values have no escapes and may span lines
+		end := strings.IndexByte(l.src[l.pos+1:], c)
+		if end == -1 {
+			l.pos++
+			l.emit(TokenPunct, start, "")
+			return
+		}
+		l.pos += 1 + end + 1
+		l.emit(TokenString, start, l.src[start+1:l.pos-1])
+	case c == '{':
+		l.pos++
+		l.emit(TokenPunct, start, "")
+		l.push(lexMode{kind: modeCode})
+	case c == '/' && l.peek(1) == '>':
+		l.pos += 2
+		l.emit(TokenPunct, start, "")
+		l.pop()
+		l.endJSXElement()
+	case c == '>':
+		l.pos++
+		l.emit(TokenPunct, start, "")
+		closing := l.top().closing
+		l.pop()
+		if closing {
+			// pop the children of the element being closed
+			l.pop()
+			l.endJSXElement()
+			return
+		}
+		l.push(lexMode{kind: modeJSXChildren})
+	default:
+		l.pos++
+		l.emit(TokenPunct, start, "")
+	}
+}
+
+// endJSXElement is called after a complete element, when back in code the element acts as a finished operand.
+func (l *lexer) endJSXElement() {
+	if l.top().kind == modeCode {
+		l.afterJSX = true
+	}
+}
+
+func (l *lexer) lexJSXChildren() {
+	start := l.pos
+	for l.pos < len(l.src) && l.src[l.pos] != '<' && l.src[l.pos] != '{' {
+		l.pos++
+	}
+	if l.pos > start {
+		l.emit(TokenJSXText, start, l.src[start:l.pos])
+	}
+	if l.pos >= len(l.src) {
+		return
+	}
+	start = l.pos
+	if l.src[l.pos] == '{' {
+		l.pos++
+		l.emit(TokenPunct, start, "")
+		l.push(lexMode{kind: modeCode})
+		return
+	}
+	// <
+	i := l.pos + 1
+	for i < len(l.src) && isSpace(l.src[i]) {
+		i++
+	}
+	if i < len(l.src) && l.src[i] == '/' {
+		l.pos = i + 1
+		l.emit(TokenPunct, start, "")
+		l.push(lexMode{kind: modeJSXTag, closing: true})
+		return
+	}
+	l.pos++
+	l.emit(TokenPunct, start, "")
+	l.push(lexMode{kind: modeJSXTag})
+}
+
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
+
+// unescape decodes JS string escape sequences.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch e := s[i]; e {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case '\r':
+			// line continuation
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+			// line continuation
+		case 'x':
+			if i+2 < len(s) {
+				if n, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+					b.WriteRune(rune(n))
+					i += 2
+					continue
+				}
+			}
+			b.WriteByte(e)
+		case 'u':
+			hex := ""
+			if i+1 < len(s) && s[i+1] == '{' {
+				if end := strings.IndexByte(s[i:], '}'); end != -1 {
+					hex = s[i+2 : i+end]
+					if n, err := strconv.ParseUint(hex, 16, 32); err == nil {
+						b.WriteRune(rune(n))
+						i += end
+						continue
+					}
+				}
+			} else if i+4 < len(s) {
+				if n, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					i += 4
+					r := rune(n)
+					// surrogate pair
+					if r >= 0xD800 && r < 0xDC00 && i+6 < len(s) && s[i+1] == '\\' && s[i+2] == 'u' {
+						if lo, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil && lo >= 0xDC00 && lo < 0xE000 {
+							r = (r-0xD800)<<10 + (rune(lo) - 0xDC00) + 0x10000
+							i += 6
+						}
+					}
+					b.WriteRune(r)
+					continue
+				}
+			}
+			b.WriteByte(e)
+		default:
+			b.WriteByte(e)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package extractor
+
+import (
+	"bytes"
+	"sort"
+	"unicode/utf8"
+)
+
+// LineIndex maps byte offsets to line and column numbers. It is built with a single pass over the data, so looking up many positions in a file stays linear.
+type LineIndex struct {
+	data []byte
+	// starts holds the offset of the first byte of every line
+	starts []int
+}
+
+func NewLineIndex(data []byte) *LineIndex {
+	starts := make([]int, 1, bytes.Count(data, []byte("\n"))+1)
+	for i, c := range data {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return &LineIndex{data: data, starts: starts}
+}
+
+// Position returns the 1-based line and column for the byte offset. Columns count characters, not bytes.
+func (li *LineIndex) Position(offset int) (line, col int) {
+	if offset > len(li.data) {
+		offset = len(li.data)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	i := sort.Search(len(li.starts), func(i int) bool { return li.starts[i] > offset }) - 1
+	return i + 1, utf8.RuneCount(li.data[li.starts[i]:offset]) + 1
+}
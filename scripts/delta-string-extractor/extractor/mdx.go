@@ -0,0 +1,103 @@
+package extractor
+
+import (
+	"bytes"
+	"strings"
+)
+
+// IsMDXFile reports whether the file is MDX, markdown with JSX and JS expressions.
+func IsMDXFile(file string) bool {
+	return strings.HasSuffix(file, ".mdx")
+}
+
+// MDXCode returns the JS parts of an MDX file: import and export statements and {expressions}, including the ones in JSX attributes. Everything else, including fenced code blocks and `code spans`, is replaced by spaces. Newlines and byte offsets are kept, so positions in the result are positions in src.
+func MDXCode(src []byte) []byte {
+	out := bytes.Repeat([]byte{' '}, len(src))
+	for i, c := range src {
+		if c == '\n' {
+			out[i] = '\n'
+		}
+	}
+
+	fence := ""
+	// set while copying a multi-line import or export statement, it ends at a blank line
+	inStatement := false
+	for pos := 0; pos < len(src); {
+		end := bytes.IndexByte(src[pos:], '\n')
+		if end < 0 {
+			end = len(src)
+		} else {
+			end += pos
+		}
+		line := string(src[pos:end])
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		case inStatement && trimmed == "":
+			inStatement = false
+		case inStatement || strings.HasPrefix(line, "import ") || strings.HasPrefix(line, "export "):
+			copy(out[pos:end], src[pos:end])
+			inStatement = true
+		default:
+			if next := copyExpressions(src, out, pos, end); next > end {
+				// the expression continued on the following lines, scan the rest of its last line
+				pos = next
+				continue
+			}
+		}
+		pos = end + 1
+	}
+	return out
+}
+
+// copyExpressions copies the {expressions} starting on the line src[pos:end] to out and returns the offset after the last one.
+func copyExpressions(src, out []byte, pos, end int) int {
+	last := pos
+	for i := pos; i < end && i < len(src); i++ {
+		switch src[i] {
+		case '`':
+			// code span
+			if j := bytes.IndexByte(src[i+1:end], '`'); j >= 0 {
+				i += j + 1
+			}
+		case '{':
+			close := matchBrace(src, i)
+			copy(out[i:close], src[i:close])
+			last = close
+			if close > end {
+				return close
+			}
+			i = close - 1
+		}
+	}
+	return last
+}
+
+// matchBrace returns the offset after the } closing the { at i, skipping braces in strings. Unclosed braces extend to the end of src.
+func matchBrace(src []byte, i int) int {
+	depth := 0
+	for ; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '"', '\'', '`':
+			for i++; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(src)
+}
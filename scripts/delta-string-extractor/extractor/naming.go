@@ -0,0 +1,180 @@
+package extractor
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// NamingRules are conventions for translation codes, such as analysis.affected_people. The zero value checks nothing.
+type NamingRules struct {
+	// Pattern is a regular expression the whole code must match, for example ^[a-z0-9_]+(\.[a-z0-9_]+)*$
+	Pattern string `json:"pattern,omitempty"`
+	// MaxDepth is the maximum number of dot separated parts, 0 for no limit
+	MaxDepth int `json:"maxDepth,omitempty"`
+	// Namespaces lists the allowed first parts of codes, empty to allow any
+	Namespaces []string `json:"namespaces,omitempty"`
+	// MatchDirectory requires the namespace to match a directory or file name of the call site, see pathNames
+	MatchDirectory bool `json:"matchDirectory,omitempty"`
+	// SharedNamespaces can be used from any directory, for example common
+	SharedNamespaces []string `json:"sharedNamespaces,omitempty"`
+	// NearDuplicates reports codes that only differ by case or separators, see CheckNearDuplicates
+	NearDuplicates bool `json:"nearDuplicates,omitempty"`
+}
+
+// Validate checks that Pattern is a valid regular expression.
+func (r NamingRules) Validate() error {
+	if r.Pattern == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("invalid naming pattern %q: %w", r.Pattern, err)
+	}
+	return nil
+}
+
+// namingChecker applies NamingRules to the codes of a single file.
+type namingChecker struct {
+	rules   NamingRules
+	pattern *regexp.Regexp
+	// names are the normalized directory and file names of the file
+	names map[string]bool
+}
+
+func newNamingChecker(rules NamingRules, file string) *namingChecker {
+	c := &namingChecker{rules: rules}
+	if rules.Pattern != "" {
+		// an invalid pattern is rejected by Validate, here it only disables the check
+		c.pattern, _ = regexp.Compile(rules.Pattern)
+	}
+	if rules.MatchDirectory {
+		c.names = pathNames(file)
+	}
+	return c
+}
+
+func (c *namingChecker) check(diags *diagnostics, pos int, code string) {
+	if code == "" {
+		return
+	}
+	if c.pattern != nil && !c.pattern.MatchString(code) {
+		diags.add(RuleCodeFormat, pos, "code %q does not match %s", code, c.rules.Pattern)
+	}
+	parts := strings.Split(code, ".")
+	if c.rules.MaxDepth > 0 && len(parts) > c.rules.MaxDepth {
+		diags.add(RuleCodeFormat, pos, "code %q has %d parts, at most %d are allowed", code, len(parts), c.rules.MaxDepth)
+	}
+	ns := parts[0]
+	if len(parts) == 1 {
+		// a code without a dot has no namespace
+		ns = ""
+	}
+	if len(c.rules.Namespaces) != 0 && !inSlice(c.rules.Namespaces, ns) {
+		diags.add(RuleCodeNamespace, pos, "code %q does not start with an allowed namespace (%s)", code, strings.Join(c.rules.Namespaces, ", "))
+	}
+	if c.rules.MatchDirectory && ns != "" && !inSlice(c.rules.SharedNamespaces, ns) && !c.names[normalizeName(ns)] {
+		diags.add(RuleCodeDirectory, pos, "namespace %q of code %q does not match the directory of %s", ns, code, diags.file)
+	}
+}
+
+// pathNames returns the names a namespace can match for a file. These are the directory names and the file name up to the first dot, each on its own and joined with the next one, so user+/login.tsx matches both user and user_login. Names are normalized with normalizeName.
+func pathNames(file string) map[string]bool {
+	parts := strings.Split(path.Dir(strings.ReplaceAll(file, "\\", "/")), "/")
+	base, _, _ := strings.Cut(path.Base(strings.ReplaceAll(file, "\\", "/")), ".")
+	parts = append(parts, base)
+
+	res := make(map[string]bool)
+	prev := ""
+	for _, part := range parts {
+		name := normalizeName(part)
+		if name == "" {
+			continue
+		}
+		res[name] = true
+		if prev != "" {
+			res[prev+name] = true
+		}
+		prev = name
+	}
+	return res
+}
+
+// normalizeName lowercases s and drops everything but letters and digits, so ContentRepeater, content-repeater+ and content_repeater are equal. A trailing s is removed so singular and plural forms match.
+func normalizeName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "s")
+}
+
+// nearDuplicateKey is equal for codes that only differ by case or separators.
+func nearDuplicateKey(code string) string {
+	var b strings.Builder
+	for _, r := range code {
+		switch r {
+		case '.', '_', '-', ' ':
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// CheckNearDuplicates reports codes that only differ by case or separators, for example common.expandAll and common.expand_all. It needs the entries of all files, so unlike the other rules it is not part of Extract. Each differing code is reported once, at its first location.
+func CheckNearDuplicates(entries []Entry, cfg Config) []Diagnostic {
+	if !cfg.Naming.NearDuplicates {
+		return nil
+	}
+	sev := cfg.Severity(RuleCodeNearDuplicate)
+	if sev == SeverityOff {
+		return nil
+	}
+
+	first := make(map[string]Entry)
+	groups := make(map[string][]string)
+	for _, e := range entries {
+		if e.Code == "" {
+			continue
+		}
+		if _, ok := first[e.Code]; ok {
+			continue
+		}
+		first[e.Code] = e
+		key := nearDuplicateKey(e.Code)
+		groups[key] = append(groups[key], e.Code)
+	}
+
+	var res []Diagnostic
+	for _, codes := range groups {
+		if len(codes) < 2 {
+			continue
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			var others []string
+			for _, c := range codes {
+				if c != code {
+					others = append(others, c)
+				}
+			}
+			e := first[code]
+			file, line, col := e.Position()
+			res = append(res, Diagnostic{
+				Rule:     RuleCodeNearDuplicate,
+				Severity: sev,
+				File:     file,
+				Line:     line,
+				Column:   col,
+				Message:  fmt.Sprintf("code %q only differs by case or separators from %s", code, strings.Join(others, ", ")),
+			})
+		}
+	}
+	SortDiagnostics(res)
+	return res
+}
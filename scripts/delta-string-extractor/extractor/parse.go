@@ -0,0 +1,296 @@
+package extractor
+
+import "strings"
+
+type ValueKind int
+
+const (
+	// ValueExpr is any expression that is not a literal, Raw holds its source.
+	ValueExpr ValueKind = iota
+	ValueString
+	ValueNumber
+	ValueArray
+	ValueObject
+)
+
+// Value is a statically parsed JS literal.
+type Value struct {
+	Kind ValueKind
+	// Str is the decoded string for ValueString and the raw number for ValueNumber.
+	Str   string
+	Items []Value
+	Props []Prop
+	// Raw is the source text of the value.
+	Raw string
+	// Pos and End are byte offsets into the source.
+	Pos int
+	End int
+}
+
+// Prop is a property of an object literal.
+type Prop struct {
+	// Key is empty for computed keys and spreads.
+	Key    string
+	Spread bool
+	// KeyKind is the token of a literal key, TokenIdent, TokenString or TokenNumber, and Assigned is set for key: value properties, not for shorthands and methods
+	KeyKind  TokenKind
+	Assigned bool
+	Value    Value
+	Pos      int
+}
+
+// Get returns the value of the last property with the given key. Later properties override earlier ones, same as in JS.
+func (v Value) Get(key string) (Value, bool) {
+	var res Value
+	found := false
+	for _, p := range v.Props {
+		if !p.Spread && p.Key == key {
+			res = p.Value
+			found = true
+		}
+	}
+	return res, found
+}
+
+// HasSpread reports whether an object literal contains ...spread or computed keys, in which case its properties are not fully known.
+func (v Value) HasSpread() bool {
+	for _, p := range v.Props {
+		if p.Spread || p.Key == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// parser reads literal values from significant (non-comment) tokens.
+type parser struct {
+	src  string
+	toks []Token
+}
+
+func newParser(src []byte, toks []Token) *parser {
+	var sig []Token
+	for _, t := range toks {
+		if t.Kind != TokenComment {
+			sig = append(sig, t)
+		}
+	}
+	return &parser{src: string(src), toks: sig}
+}
+
+func (p *parser) tok(i int) Token {
+	if i < 0 {
+		return Token{Kind: TokenEOF}
+	}
+	if i < len(p.toks) {
+		return p.toks[i]
+	}
+	return p.toks[len(p.toks)-1]
+}
+
+func (p *parser) isPunct(i int, s string) bool {
+	t := p.tok(i)
+	return t.Kind == TokenPunct && t.Text == s
+}
+
+// isTerminator reports whether the token ends a value inside an object, array or argument list.
+func (p *parser) isTerminator(i int) bool {
+	t := p.tok(i)
+	if t.Kind == TokenEOF {
+		return true
+	}
+	if t.Kind != TokenPunct {
+		return false
+	}
+	switch t.Text {
+	case ",", "}", "]", ")", ";":
+		return true
+	}
+	return false
+}
+
+// parseValue parses the value starting at token i and returns it with the index of the first token after it. Anything that is not a plain literal is returned as ValueExpr.
+func (p *parser) parseValue(i int) (Value, int) {
+	start := i
+	v, next, ok := p.parseLiteral(i)
+	if ok && p.isTerminator(next) {
+		return v, next
+	}
+	next = p.skipExpr(start)
+	return p.expr(start, next), next
+}
+
+func (p *parser) expr(start, next int) Value {
+	pos := p.tok(start).Pos
+	end := pos
+	if next > start {
+		end = p.tok(next - 1).End
+	}
+	return Value{Kind: ValueExpr, Raw: p.src[pos:end], Pos: pos, End: end}
+}
+
+func (p *parser) parseLiteral(i int) (Value, int, bool) {
+	t := p.tok(i)
+	switch {
+	case t.Kind == TokenString || t.Kind == TokenTemplate:
+		return Value{Kind: ValueString, Str: t.Value, Raw: t.Text, Pos: t.Pos, End: t.End}, i + 1, true
+	case t.Kind == TokenNumber:
+		return Value{Kind: ValueNumber, Str: t.Text, Raw: t.Text, Pos: t.Pos, End: t.End}, i + 1, true
+	case t.Kind == TokenPunct && t.Text == "-" && p.tok(i+1).Kind == TokenNumber:
+		n := p.tok(i + 1)
+		return Value{Kind: ValueNumber, Str: "-" + n.Text, Raw: p.src[t.Pos:n.End], Pos: t.Pos, End: n.End}, i + 2, true
+	case t.Kind == TokenPunct && t.Text == "[":
+		return p.parseArray(i)
+	case t.Kind == TokenPunct && t.Text == "{":
+		return p.parseObject(i)
+	}
+	return Value{}, i, false
+}
+
+func (p *parser) parseArray(i int) (Value, int, bool) {
+	open := p.tok(i)
+	v := Value{Kind: ValueArray, Pos: open.Pos}
+	i++
+	for {
+		if p.isPunct(i, "]") {
+			break
+		}
+		if p.tok(i).Kind == TokenEOF {
+			return Value{}, i, false
+		}
+		if p.isPunct(i, "...") {
+			next := p.skipExpr(i + 1)
+			v.Items = append(v.Items, p.expr(i, next))
+			i = next
+		} else {
+			var item Value
+			item, i = p.parseValue(i)
+			v.Items = append(v.Items, item)
+		}
+		if p.isPunct(i, ",") {
+			i++
+			continue
+		}
+		if !p.isPunct(i, "]") {
+			return Value{}, i, false
+		}
+	}
+	v.End = p.tok(i).End
+	v.Raw = p.src[v.Pos:v.End]
+	return v, i + 1, true
+}
+
+func (p *parser) parseObject(i int) (Value, int, bool) {
+	open := p.tok(i)
+	v := Value{Kind: ValueObject, Pos: open.Pos}
+	i++
+	for {
+		if p.isPunct(i, "}") {
+			break
+		}
+		t := p.tok(i)
+		prop := Prop{Pos: t.Pos}
+		switch {
+		case t.Kind == TokenEOF:
+			return Value{}, i, false
+		case t.Kind == TokenPunct && t.Text == "...":
+			prop.Spread = true
+			next := p.skipExpr(i + 1)
+			prop.Value = p.expr(i+1, next)
+			i = next
+		case t.Kind == TokenPunct && t.Text == "[":
+			// computed key
+			i = p.skipBalanced(i)
+			if !p.isPunct(i, ":") {
+				return Value{}, i, false
+			}
+			prop.Assigned = true
+			prop.Value, i = p.parseValue(i + 1)
+		case t.Kind == TokenIdent || t.Kind == TokenString || t.Kind == TokenNumber:
+			prop.Key = t.Text
+			prop.KeyKind = t.Kind
+			if t.Kind == TokenString {
+				prop.Key = t.Value
+			}
+			i++
+			switch {
+			case p.isPunct(i, ":"):
+				prop.Assigned = true
+				prop.Value, i = p.parseValue(i + 1)
+			case p.isPunct(i, ",") || p.isPunct(i, "}"):
+				// shorthand
+				prop.Value = p.expr(i-1, i)
+			default:
+				// method, getter or setter
+				next := p.skipExpr(i)
+				prop.Value = p.expr(i, next)
+				i = next
+			}
+		default:
+			return Value{}, i, false
+		}
+		v.Props = append(v.Props, prop)
+		if p.isPunct(i, ",") {
+			i++
+			continue
+		}
+		if !p.isPunct(i, "}") {
+			return Value{}, i, false
+		}
+	}
+	v.End = p.tok(i).End
+	v.Raw = p.src[v.Pos:v.End]
+	return v, i + 1, true
+}
+
+// skipBalanced skips a bracketed group starting at token i and returns the index after the closing bracket.
+func (p *parser) skipBalanced(i int) int {
+	depth := 0
+	for ; ; i++ {
+		t := p.tok(i)
+		if t.Kind == TokenEOF {
+			return i
+		}
+		if opensGroup(t) {
+			depth++
+		} else if closesGroup(t) {
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+}
+
+// skipExpr skips tokens up to the next terminator that is not nested in brackets.
+func (p *parser) skipExpr(i int) int {
+	depth := 0
+	for ; ; i++ {
+		t := p.tok(i)
+		if t.Kind == TokenEOF {
+			return i
+		}
+		if depth == 0 && p.isTerminator(i) {
+			return i
+		}
+		if opensGroup(t) {
+			depth++
+		} else if closesGroup(t) {
+			depth--
+		}
+	}
+}
+
+func opensGroup(t Token) bool {
+	if t.Kind == TokenTemplateHead {
+		return true
+	}
+	return t.Kind == TokenPunct && strings.Contains("([{", t.Text) && len(t.Text) == 1
+}
+
+func closesGroup(t Token) bool {
+	if t.Kind == TokenTemplateTail {
+		return true
+	}
+	return t.Kind == TokenPunct && strings.Contains(")]}", t.Text) && len(t.Text) == 1
+}
@@ -0,0 +1,118 @@
+package extractor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var placeholderRE = regexp.MustCompile(`{([^{}]+)}`)
+
+// Placeholders returns the names of all {name} placeholders in msg, in order of first use.
+func Placeholders(msg string) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, m := range placeholderRE.FindAllStringSubmatch(msg, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			res = append(res, m[1])
+		}
+	}
+	return res
+}
+
+// bracesBalanced reports whether every { in msg is closed by a } before the next {.
+func bracesBalanced(msg string) bool {
+	open := false
+	for _, c := range msg {
+		switch c {
+		case '{':
+			if open {
+				return false
+			}
+			open = true
+		case '}':
+			if !open {
+				return false
+			}
+			open = false
+		}
+	}
+	return !open
+}
+
+// messages returns all message strings of the entry, with the plural category for msgs.
+func (e Entry) messages() map[string]string {
+	if len(e.Msgs) != 0 {
+		return e.Msgs
+	}
+	return map[string]string{"": e.Msg}
+}
+
+// replacements is the second argument of a translation call.
+type replacements struct {
+	// known is false if the argument is not an object literal, then its keys can not be checked
+	known bool
+	value Value
+}
+
+// parseReplacements reads the optional argument after the first one, next is the index of the token after the first argument.
+func (p *parser) parseReplacements(next int) replacements {
+	if !p.isPunct(next, ",") || p.isPunct(next+1, ")") {
+		// no second argument
+		return replacements{known: true}
+	}
+	v, _ := p.parseValue(next + 1)
+	if v.Kind != ValueObject || v.HasSpread() {
+		return replacements{value: v}
+	}
+	return replacements{known: true, value: v}
+}
+
+// checkPlaceholders compares the {placeholder} tokens in the messages of the entry with the keys of the replacements.
+func checkPlaceholders(diags *diagnostics, pos int, e Entry, repl replacements) {
+	msgs := e.messages()
+	forms := make([]string, 0, len(msgs))
+	for form := range msgs {
+		forms = append(forms, form)
+	}
+	sort.Strings(forms)
+
+	used := make(map[string]bool)
+	for _, form := range forms {
+		msg := msgs[form]
+		name := "msg"
+		if form != "" {
+			name = "msgs." + form
+		}
+		if !bracesBalanced(msg) {
+			diags.add(RuleUnbalancedBraces, pos, "%s has unbalanced braces: %q", name, msg)
+		}
+		for _, ph := range Placeholders(msg) {
+			used[ph] = true
+			if !repl.known {
+				continue
+			}
+			if _, ok := repl.value.Get(ph); !ok {
+				diags.add(RulePlaceholderMissing, pos, "%s uses placeholder {%s} but no replacement is passed for it", name, ph)
+			}
+		}
+	}
+	if !repl.known {
+		return
+	}
+
+	var unused []string
+	for _, prop := range repl.value.Props {
+		if !used[prop.Key] {
+			unused = append(unused, prop.Key)
+		}
+	}
+	// plural messages need a number to pick the form, it does not have to appear in the text
+	if len(e.Msgs) != 0 && len(unused) == 1 {
+		return
+	}
+	if len(unused) != 0 {
+		diags.add(RulePlaceholderUnused, pos, "replacements %s are not used in the message", strings.Join(unused, ", "))
+	}
+}
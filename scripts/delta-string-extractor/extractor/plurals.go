@@ -0,0 +1,58 @@
+package extractor
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PluralCategories are the CLDR plural categories that Intl.PluralRules can select.
+var PluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// checkPlural validates msgs the same way createTranslator in app/utils/translator.ts uses them at runtime. The form is picked with Intl.PluralRules from the first integer in the replacements and falls back to other.
+func checkPlural(diags *diagnostics, pos int, e Entry, repl replacements) {
+	if len(e.Msgs) == 0 {
+		return
+	}
+	var invalid []string
+	for form := range e.Msgs {
+		if !inSlice(PluralCategories, form) {
+			invalid = append(invalid, form)
+		}
+	}
+	sort.Strings(invalid)
+	if len(invalid) != 0 {
+		diags.add(RuleBadPlural, pos, "msgs has keys that are not CLDR plural categories (%s): %s", strings.Join(PluralCategories, ", "), strings.Join(invalid, ", "))
+	}
+	if _, ok := e.Msgs["other"]; !ok {
+		diags.add(RuleBadPlural, pos, "msgs has no \"other\" form, it is the fallback for all languages")
+	}
+	if repl.known && len(countKeys(repl.value)) == 0 {
+		diags.add(RuleBadPlural, pos, "plural message without a number in the replacements, it is needed to pick the form")
+	}
+}
+
+// countKeys returns the keys of an object literal with an integer literal value or a value that is not a literal and may be a number at runtime, in order. createTranslator picks the form with the first integer value.
+func countKeys(v Value) []string {
+	var keys []string
+	for _, p := range v.Props {
+		switch p.Value.Kind {
+		case ValueExpr:
+			keys = append(keys, p.Key)
+		case ValueNumber:
+			if _, err := strconv.ParseInt(strings.ReplaceAll(p.Value.Str, "_", ""), 0, 64); err == nil {
+				keys = append(keys, p.Key)
+			}
+		}
+	}
+	return keys
+}
+
+func inSlice(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
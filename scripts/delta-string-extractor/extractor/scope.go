@@ -0,0 +1,55 @@
+package extractor
+
+// scopes returns for each token the name of the top-level declaration it belongs to, for example the component Screen or the route loader in
+//
+//	export const loader = authLoader(async () => { ... })
+//	export default function Screen() { ... }
+//
+// Nested functions are part of the top-level one. Anonymous default exports are named default, tokens outside of declarations have an empty scope.
+func (p *parser) scopes() []string {
+	res := make([]string, len(p.toks))
+	depth := 0
+	current := ""
+	// set for function and class declarations, which end with their body instead of a semicolon
+	endsWithBody := false
+	for i, t := range p.toks {
+		if depth == 0 && t.Kind == TokenIdent && !p.isPunct(i-1, ".") {
+			next := p.tok(i + 1)
+			switch t.Text {
+			case "default":
+				if p.tok(i-1).Text == "export" {
+					current = "default"
+					endsWithBody = false
+				}
+			case "function", "class":
+				if p.isPunct(i+1, "*") {
+					next = p.tok(i + 2)
+				}
+				if next.Kind == TokenIdent && next.Text != "extends" {
+					current = next.Text
+				}
+				endsWithBody = true
+			case "const", "let", "var":
+				if next.Kind == TokenIdent {
+					current = next.Text
+					endsWithBody = false
+				}
+			}
+		}
+		res[i] = current
+
+		switch {
+		case opensGroup(t):
+			depth++
+		case closesGroup(t):
+			depth--
+			if depth == 0 && endsWithBody && t.Text == "}" {
+				current = ""
+				endsWithBody = false
+			}
+		case depth == 0 && t.Kind == TokenPunct && t.Text == ";":
+			current = ""
+		}
+	}
+	return res
+}
@@ -0,0 +1,146 @@
+package extractor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExtractTS returns the translation calls of a file the way scripts/extractor-i18n.ts reads them with ts-morph, to reproduce its output. Unlike Extract:
+//   - only .ts and .tsx files are read, and every call whose callee ends in .t, also calls nested in the arguments of another call
+//   - the first key: value property with an identifier or string name is used, not the last one
+//   - code, desc, msg, the elements of a msg array and the forms of msgs are only read from quoted strings, templates are not string literals
+//   - a msg that is not read or empty falls back to msgs, forms with other values are left out, and calls without a message are skipped
+//   - a desc that is not a string is left out
+//
+// The entries have Code, Msg or Msgs with Forms in the order of the JS object, Desc, Location, Column and Callee. Problems are not reported, Extract reports them.
+func ExtractTS(file string, data []byte) []Entry {
+	if !strings.HasSuffix(file, ".ts") && !strings.HasSuffix(file, ".tsx") {
+		return nil
+	}
+	toks := Tokenize(data, IsJSXFile(file))
+	p := newParser(data, toks)
+	lines := NewLineIndex(data)
+	var res []Entry
+
+	for i := 0; i < len(p.toks); i++ {
+		// the text of the callee expression ends in .t
+		dot := p.tok(i - 1)
+		if !p.isCall(i) || p.tok(i).Text != "t" || dot.End != p.tok(i).Pos || !p.isPunct(i-1, ".") && !p.isPunct(i-1, "?.") {
+			continue
+		}
+		callee, calleeStart := p.callee(i)
+		if t := p.tok(calleeStart - 1); t.Kind == TokenIdent && t.Text == "new" {
+			continue
+		}
+		if !p.isPunct(i+2, "{") {
+			continue
+		}
+		// calls nested in the object are visited too
+		obj, _ := p.parseValue(i + 2)
+		if obj.Kind != ValueObject {
+			continue
+		}
+
+		code, ok := tsProp(obj, "code")
+		if !ok || !isStringLiteral(code) {
+			continue
+		}
+		e := Entry{Code: code.Str, Callee: callee}
+		if v, ok := tsProp(obj, "msg"); ok {
+			e.Msg = tsMsg(v)
+		}
+		if v, ok := tsProp(obj, "msgs"); ok && e.Msg == "" {
+			e.Msgs, e.Forms = tsMsgs(v)
+		}
+		if e.Msg == "" && len(e.Msgs) == 0 {
+			continue
+		}
+		if v, ok := tsProp(obj, "desc"); ok && isStringLiteral(v) {
+			e.Desc = v.Str
+		}
+		line, col := lines.Position(p.tok(calleeStart).Pos)
+		e.Location = fmt.Sprintf("%v:%v", file, line)
+		e.Column = col
+		res = append(res, e)
+	}
+	return res
+}
+
+// isStringLiteral reports whether v is a quoted string. ts-morph does not read templates as string literals, not even without substitutions.
+func isStringLiteral(v Value) bool {
+	return v.Kind == ValueString && !strings.HasPrefix(v.Raw, "`")
+}
+
+// tsProp returns the value of the first key: value property named key, as getObjectProp in scripts/extractor-i18n.ts.
+func tsProp(obj Value, key string) (Value, bool) {
+	for _, p := range obj.Props {
+		if tsKey(p) && p.Key == key {
+			return p.Value, true
+		}
+	}
+	return Value{}, false
+}
+
+// tsKey reports whether p is a key: value property with an identifier or string name.
+func tsKey(p Prop) bool {
+	return p.Assigned && (p.KeyKind == TokenIdent || p.KeyKind == TokenString)
+}
+
+// tsMsg returns a string or the lines of an array of strings, or an empty string for other values, as extractMsg in scripts/extractor-i18n.ts.
+func tsMsg(v Value) string {
+	if isStringLiteral(v) {
+		return v.Str
+	}
+	if v.Kind != ValueArray {
+		return ""
+	}
+	parts := make([]string, len(v.Items))
+	for i, item := range v.Items {
+		if !isStringLiteral(item) {
+			return ""
+		}
+		parts[i] = item.Str
+	}
+	return strings.Join(parts, "\n")
+}
+
+// tsMsgs returns the forms of a msgs object with string values, as extractPlural in scripts/extractor-i18n.ts. The forms are in the order of the JS object it builds: names that are array indexes in ascending order first, then the others in the order they were first set.
+func tsMsgs(v Value) (map[string]string, []string) {
+	if v.Kind != ValueObject {
+		return nil, nil
+	}
+	msgs := make(map[string]string)
+	var forms []string
+	for _, p := range v.Props {
+		if !tsKey(p) || p.Key == "" || !isStringLiteral(p.Value) {
+			continue
+		}
+		if _, ok := msgs[p.Key]; !ok {
+			forms = append(forms, p.Key)
+		}
+		msgs[p.Key] = p.Value.Str
+	}
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(forms, func(i, j int) bool {
+		a, aIndex := arrayIndex(forms[i])
+		b, bIndex := arrayIndex(forms[j])
+		if aIndex && bIndex {
+			return a < b
+		}
+		return aIndex && !bIndex
+	})
+	return msgs, forms
+}
+
+// arrayIndex reports whether a property name is an array index, JS objects list those first.
+func arrayIndex(name string) (uint64, bool) {
+	n, err := strconv.ParseUint(name, 10, 32)
+	if err != nil || n == 1<<32-1 || strconv.FormatUint(n, 10) != name {
+		return 0, false
+	}
+	return n, true
+}
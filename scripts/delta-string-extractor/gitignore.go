@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a pattern of a .gitignore file.
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+	// anchored patterns contain a slash and match the path relative to the .gitignore, others match the name at any depth
+	anchored bool
+}
+
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	var r ignoreRule
+	if rest, ok := strings.CutPrefix(line, "!"); ok {
+		r.negate = true
+		line = rest
+	}
+	line = strings.TrimPrefix(line, "\\")
+	if rest, ok := strings.CutSuffix(line, "/"); ok {
+		r.dirOnly = true
+		line = rest
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	r.pattern = line
+	return r, true
+}
+
+// match reports whether the rule matches rel, the slash separated path relative to the directory of the .gitignore.
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		return matchGlob(r.pattern, rel)
+	}
+	return matchGlob(r.pattern, path.Base(rel))
+}
+
+// gitignore applies the .gitignore files of a git repository, including the ones in parent directories of the scanned directory. It supports the common syntax: comments, negation, directory-only patterns, anchored patterns and **. Global excludes and .git/info/exclude are not read.
+type gitignore struct {
+	// top is the repository root, or the scanned directory outside of a repository
+	top   string
+	rules map[string][]ignoreRule
+}
+
+func newGitignore(dir string) (*gitignore, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	top := findRepoRoot(abs)
+	if top == "" {
+		top = abs
+	}
+	return &gitignore{top: top, rules: make(map[string][]ignoreRule)}, nil
+}
+
+// load returns the rules of the .gitignore in dir, a slash separated path relative to top.
+func (g *gitignore) load(dir string) []ignoreRule {
+	if rules, ok := g.rules[dir]; ok {
+		return rules
+	}
+	var rules []ignoreRule
+	if f, err := os.Open(filepath.Join(g.top, filepath.FromSlash(dir), ".gitignore")); err == nil {
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			if r, ok := parseIgnoreRule(s.Text()); ok {
+				rules = append(rules, r)
+			}
+		}
+		f.Close()
+	}
+	g.rules[dir] = rules
+	return rules
+}
+
+// ignored reports whether absPath is ignored. The last matching rule wins, rules of deeper .gitignore files come later.
+func (g *gitignore) ignored(absPath string, isDir bool) bool {
+	rel, err := filepath.Rel(g.top, absPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	parts := strings.Split(rel, "/")
+	ignored := false
+	for i := 0; i < len(parts); i++ {
+		dir := "."
+		if i > 0 {
+			dir = strings.Join(parts[:i], "/")
+		}
+		sub := strings.Join(parts[i:], "/")
+		for _, r := range g.load(dir) {
+			if r.match(sub, isDir) {
+				ignored = !r.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// matchGlob matches a slash separated name against a pattern where ** matches any number of path segments, including none, and the other segments use path.Match syntax.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"delta-string-extractor/extractor"
+)
+
+// dirCoverage counts the translated and hardcoded strings of the files directly in a directory.
+type dirCoverage struct {
+	Translated int `json:"translated"`
+	Hardcoded  int `json:"hardcoded"`
+}
+
+// percent returns the share of translated strings, 100 for a directory without strings.
+func (c dirCoverage) percent() float64 {
+	if c.Translated+c.Hardcoded == 0 {
+		return 100
+	}
+	return 100 * float64(c.Translated) / float64(c.Translated+c.Hardcoded)
+}
+
+// runHardcoded reports user-visible JSX text and attributes that bypass translation calls, along with the translation coverage per directory.
+func runHardcoded(args []string) error {
+	fs := flag.NewFlagSet("hardcoded", flag.ExitOnError)
+	ef := registerExtractFlags(fs)
+	countsFile := fs.String("counts-file", "", "optional file to write the translated and hardcoded counts per directory to, as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := ef.config()
+	if err != nil {
+		return err
+	}
+	roots := ef.roots(root{})
+	cache := loadFileCache(*ef.cacheFile, cacheFingerprint(cfg))
+
+	var diags []extractor.Diagnostic
+	coverage := make(map[string]*dirCoverage)
+	count := func(dir string) *dirCoverage {
+		if coverage[dir] == nil {
+			coverage[dir] = &dirCoverage{}
+		}
+		return coverage[dir]
+	}
+	for _, r := range roots {
+		results, err := extractRoot(r, cfg, cache, *ef.workers)
+		if err != nil {
+			return err
+		}
+		var rootDiags []extractor.Diagnostic
+		for _, res := range results {
+			dir := path.Dir(filepath.ToSlash(res.relPath))
+			if len(roots) > 1 {
+				dir = path.Join(filepath.ToSlash(r.dir), dir)
+			}
+			count(dir).Translated += len(res.entries)
+
+			data, err := os.ReadFile(filepath.Join(r.dir, res.relPath))
+			if err != nil {
+				// already reported as read-error by the extraction
+				continue
+			}
+			found := extractor.FindHardcoded(res.relPath, data, cfg)
+			count(dir).Hardcoded += len(found)
+			rootDiags = append(rootDiags, found...)
+		}
+		diags = append(diags, rootDiagnostics(roots, r, rootDiags)...)
+	}
+	if err := cache.save(); err != nil {
+		fmt.Fprintln(logw, "failed to save cache", err)
+	}
+	if err := ef.writeDiagnostics(diagnosticsDir(roots), diags); err != nil {
+		return err
+	}
+
+	dirs := make([]string, 0, len(coverage))
+	var total dirCoverage
+	for dir, c := range coverage {
+		dirs = append(dirs, dir)
+		total.Translated += c.Translated
+		total.Hardcoded += c.Hardcoded
+	}
+	sort.Strings(dirs)
+	fmt.Fprintln(logw, "Hardcoded strings by directory:")
+	for _, dir := range dirs {
+		if c := coverage[dir]; c.Hardcoded != 0 {
+			fmt.Fprintf(logw, "  %s: %d hardcoded, %d translated (%.0f%%)\n", dir, c.Hardcoded, c.Translated, c.percent())
+		}
+	}
+	fmt.Fprintf(logw, "Total: %d hardcoded, %d translated (%.1f%%)\n", total.Hardcoded, total.Translated, total.percent())
+
+	if *countsFile != "" {
+		data, err := json.MarshalIndent(coverage, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeAtomically(*countsFile, append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	if extractor.HasErrors(diags) {
+		return errDiagnostics
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package locales
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TranslationEntry is an entry of a locale file, for example locales/app/fr.json.
+type TranslationEntry struct {
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	// Context is copied from the source entry, see extractor.Entry
+	Context     string `json:"context,omitempty"`
+	Translation any    `json:"translation"`
+}
+
+func ReadTranslations(filename string) ([]TranslationEntry, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []TranslationEntry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("invalid locale file %s: %w", filename, err)
+	}
+
+	return entries, nil
+}
+
+// LanguageFiles returns the paths of the json files in dir by language, for example "fr" -> "locales/app/fr.json".
+func LanguageFiles(dir string) (map[string]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string)
+	for _, m := range matches {
+		res[strings.TrimSuffix(filepath.Base(m), ".json")] = m
+	}
+	return res, nil
+}
+
+// Languages returns the sorted language codes of files.
+func Languages(files map[string]string) []string {
+	langs := make([]string, 0, len(files))
+	for lang := range files {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// RemoveIDs rewrites filename without the entries with the given ids and returns how many were removed. Kept entries are copied as they are, so their key order and escaping do not change.
+func RemoveIDs(filename string, ids map[string]bool) (int, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	raw, err := rawEntries(filename, data)
+	if err != nil {
+		return 0, err
+	}
+	kept := make([]json.RawMessage, 0, len(raw))
+	for _, r := range raw {
+		if ids[r.id] {
+			continue
+		}
+		kept = append(kept, r.data)
+	}
+	removed := len(raw) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	out, err := formatRaw(data, kept)
+	if err != nil {
+		return 0, err
+	}
+	return removed, writeAtomically(filename, out)
+}
+
+// idProperty matches the id property of an entry. Quotes in strings are escaped, so it can not match inside a value.
+var idProperty = regexp.MustCompile(`"id"\s*:\s*("(?:[^"\\]|\\.)*")`)
+
+// FindID returns the byte offsets of the quoted id of the entry with the given id in the data of a locale file, for example to show the entry in an editor.
+func FindID(data []byte, id string) (pos, end int, ok bool) {
+	for _, loc := range idProperty.FindAllSubmatchIndex(data, -1) {
+		var s string
+		if err := json.Unmarshal(data[loc[2]:loc[3]], &s); err == nil && s == id {
+			return loc[2], loc[3], true
+		}
+	}
+	return 0, 0, false
+}
+
+// RenameIDs returns the data of locale file filename with the ids renamed by rename, and how many were renamed. Only the quoted ids change, the rest of the file is kept byte for byte.
+func RenameIDs(filename string, data []byte, rename func(id string) string) ([]byte, int, error) {
+	raw, err := rawEntries(filename, data)
+	if err != nil {
+		return nil, 0, err
+	}
+	// the id properties of the entries in file order, other objects of the file have no id
+	locs := idProperty.FindAllSubmatchIndex(data, -1)
+	if len(locs) != len(raw) {
+		return nil, 0, fmt.Errorf("invalid locale file %s: found %d ids in %d entries", filename, len(locs), len(raw))
+	}
+	var out []byte
+	renamed, last := 0, 0
+	for i, r := range raw {
+		to := rename(r.id)
+		if to == r.id {
+			continue
+		}
+		pos, end := locs[i][2], locs[i][3]
+		var id string
+		if err := json.Unmarshal(data[pos:end], &id); err != nil || id != r.id {
+			return nil, 0, fmt.Errorf("invalid entry %s in %s", r.id, filename)
+		}
+		quoted, err := json.Marshal(to)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, data[last:pos]...)
+		out = append(out, quoted...)
+		last = end
+		renamed++
+	}
+	if renamed == 0 {
+		return data, 0, nil
+	}
+	return append(out, data[last:]...), renamed, nil
+}
+
+// integerPluralForms are the plural categories Intl.PluralRules selects for integers in the languages of the app. createTranslator in app/utils/translator.ts only picks forms for integers, so for example ru never uses other.
+var integerPluralForms = map[string][]string{
+	"ar": {"zero", "one", "two", "few", "many", "other"},
+	"en": {"one", "other"},
+	"es": {"one", "many", "other"},
+	"fr": {"one", "many", "other"},
+	"ru": {"one", "few", "many"},
+	"sr": {"one", "few", "other"},
+	"zh": {"other"},
+}
+
+// PluralForms returns the plural forms a translation into lang needs. Region subtags are ignored, languages not listed in integerPluralForms only need other.
+func PluralForms(lang string) []string {
+	base, _, _ := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	if forms, ok := integerPluralForms[strings.ToLower(base)]; ok {
+		return forms
+	}
+	return []string{"other"}
+}
+
+// rawEntry is an entry of a locale file as written in the file.
+type rawEntry struct {
+	id   string
+	data json.RawMessage
+}
+
+func rawEntries(filename string, data []byte) ([]rawEntry, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid locale file %s: %w", filename, err)
+	}
+	res := make([]rawEntry, 0, len(raw))
+	for _, r := range raw {
+		var e struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(r, &e); err != nil {
+			return nil, fmt.Errorf("invalid entry in %s: %w", filename, err)
+		}
+		res = append(res, rawEntry{id: e.ID, data: r})
+	}
+	return res, nil
+}
+
+// formatRaw formats entries the same way as data, the locale file they were read from: with its indentation, tabs in locales/app and four spaces in most files of locales/content, and with a trailing newline if it has one.
+func formatRaw(data []byte, entries []json.RawMessage) ([]byte, error) {
+	indent := "\t"
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line := data[i+1:]
+		if n := len(line) - len(bytes.TrimLeft(line, " \t")); n > 0 {
+			indent = string(line[:n])
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n" + indent)
+		if err := json.Indent(&buf, e, indent, indent); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteString("\n]")
+	if bytes.HasSuffix(data, []byte("\n")) {
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// writeAtomically replaces filename through a temporary file with a random name, so concurrent runs do not clobber each other.
+func writeAtomically(filename string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		// CreateTemp creates files only readable by the owner
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,846 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"delta-string-extractor/extractor"
+	"delta-string-extractor/locales"
+)
+
+// runLSP serves a language server for translation codes over stdin and stdout, for editors to attach to .ts and .tsx files. See lspServer for what it offers.
+func runLSP(args []string) error {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	ef := registerExtractFlags(fs)
+	outputFile := fs.String("output-file", filepath.FromSlash("../../app/locales/app/en.json"), "output file path, go to definition shows the entries of codes in it")
+	localesDir := fs.String("locales-dir", filepath.FromSlash("../../locales/app"), "directory with <lang>.json locale files to show translations from")
+	sourceLang := fs.String("source-lang", "en", "language of the messages in the source, its locale file is not checked for plural forms")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// stdout carries the protocol
+	logw = os.Stderr
+
+	cfg, err := ef.config()
+	if err != nil {
+		return err
+	}
+	s, err := newLSPServer(cfg, ef.roots(root{outputFile: *outputFile}), *localesDir, *sourceLang)
+	if err != nil {
+		return err
+	}
+	cache := loadFileCache(*ef.cacheFile, cacheFingerprint(cfg))
+	if err := s.index(cache, *ef.workers); err != nil {
+		return err
+	}
+	if err := cache.save(); err != nil {
+		fmt.Fprintln(logw, "failed to save cache", err)
+	}
+	return s.serve(os.Stdin, os.Stdout)
+}
+
+// lspServer answers the requests of an editor about translation calls:
+//   - hover on a code shows its message and its translations in every locale file
+//   - go to definition jumps to the entry of the code in the output file and the source locale file, and to its other call sites
+//   - diagnostics are the ones of the extraction, conflicting messages of the code across the root and plural forms missing in the translations
+//   - completion in a code string offers the codes of the root
+//
+// All files of the roots are extracted at start. Open documents are extracted again on every change, files changed outside the editor are picked up when they are opened or on restart. Requests are handled one at a time.
+type lspServer struct {
+	cfg   extractor.Config
+	roots []root
+	// rootDirs are the absolute directories of roots
+	rootDirs   []string
+	sourceLang string
+	locales    *localeTranslations
+
+	// files are the extraction results of all files of the roots by absolute path, for open documents the result of their text in the editor
+	files map[string]lspFile
+	// docs are the open documents by URI
+	docs map[string]*lspDocument
+	// sites are the call sites of every code by root, nil when files changed since they were collected, see codeSites
+	sites map[int]map[string][]lspSite
+
+	out         io.Writer
+	writeErr    error
+	initialized bool
+	shutdown    bool
+}
+
+// lspFile is the extraction result of a file, root is the index of its root or -1 for open documents outside the roots.
+type lspFile struct {
+	root int
+	fileResult
+}
+
+type lspSite struct {
+	path  string
+	entry extractor.Entry
+}
+
+type lspDocument struct {
+	uri, path string
+	text      []byte
+}
+
+func newLSPServer(cfg extractor.Config, roots []root, localesDir, sourceLang string) (*lspServer, error) {
+	s := &lspServer{
+		cfg:        cfg,
+		roots:      roots,
+		sourceLang: sourceLang,
+		locales:    &localeTranslations{dir: localesDir, files: make(map[string]localeFile)},
+		files:      make(map[string]lspFile),
+		docs:       make(map[string]*lspDocument),
+	}
+	for _, r := range roots {
+		dir, err := filepath.Abs(r.dir)
+		if err != nil {
+			return nil, err
+		}
+		s.rootDirs = append(s.rootDirs, dir)
+	}
+	return s, nil
+}
+
+// index extracts all files of the roots.
+func (s *lspServer) index(cache *fileCache, workers int) error {
+	for i, r := range s.roots {
+		results, err := extractRoot(r, s.cfg, cache, workers)
+		if err != nil {
+			return err
+		}
+		for _, res := range results {
+			s.files[filepath.Join(s.rootDirs[i], res.relPath)] = lspFile{root: i, fileResult: res}
+		}
+	}
+	s.sites = nil
+	fmt.Fprintf(logw, "Indexed %d files\n", len(s.files))
+	return nil
+}
+
+// rootOf returns the index of the root of the file at path and the path relative to it, or -1 and the file name for files outside the roots.
+func (s *lspServer) rootOf(path string) (int, string) {
+	for i, dir := range s.rootDirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && filepath.IsLocal(rel) && inArray(s.roots[i].filter.Extensions, filepath.Ext(path)) {
+			return i, rel
+		}
+	}
+	return -1, filepath.Base(path)
+}
+
+// extract replaces the extraction result of the file at path with the one of text.
+func (s *lspServer) extract(path string, text []byte) {
+	root, rel := s.rootOf(path)
+	r := extractor.Extract(rel, text, s.cfg)
+	s.files[path] = lspFile{root: root, fileResult: fileResult{relPath: rel, entries: r.Entries, diagnostics: r.Diagnostics}}
+	s.sites = nil
+}
+
+// codeSites returns the call sites of the codes used in root, or of all roots for -1.
+func (s *lspServer) codeSites(root int) map[string][]lspSite {
+	if s.sites == nil {
+		s.sites = make(map[int]map[string][]lspSite)
+		all := make(map[string][]lspSite)
+		s.sites[-1] = all
+		for _, path := range sortedKeys(s.files) {
+			f := s.files[path]
+			if s.sites[f.root] == nil {
+				s.sites[f.root] = make(map[string][]lspSite)
+			}
+			for _, e := range f.entries {
+				site := lspSite{path: path, entry: e}
+				if f.root >= 0 {
+					s.sites[f.root][e.Code] = append(s.sites[f.root][e.Code], site)
+				}
+				all[e.Code] = append(all[e.Code], site)
+			}
+		}
+	}
+	return s.sites[root]
+}
+
+// text returns the content of the file at path, the text in the editor for open documents.
+func (s *lspServer) text(path string) ([]byte, error) {
+	for _, doc := range s.docs {
+		if doc.path == path {
+			return doc.text, nil
+		}
+	}
+	return os.ReadFile(path)
+}
+
+// serve handles messages until the client sends exit or closes the input.
+func (s *lspServer) serve(in io.Reader, out io.Writer) error {
+	s.out = out
+	r := bufio.NewReader(in)
+	for s.writeErr == nil {
+		data, err := readMessage(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var msg rpcMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.write(rpcResponse(nil, nil, &rpcError{Code: rpcParseError, Message: err.Error()}))
+			continue
+		}
+		if msg.Method == "" {
+			// a response, the server sends no requests
+			continue
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		result, err := s.handle(msg.Method, msg.Params)
+		if msg.ID == nil {
+			// notifications have no response, unknown ones such as $/cancelRequest are ignored
+			var rerr *rpcError
+			if err != nil && !(errors.As(err, &rerr) && rerr.Code == rpcMethodNotFound) {
+				fmt.Fprintf(logw, "%s: %v\n", msg.Method, err)
+			}
+			continue
+		}
+		if err != nil {
+			var rerr *rpcError
+			if !errors.As(err, &rerr) {
+				rerr = &rpcError{Code: rpcInternalError, Message: err.Error()}
+			}
+			s.write(rpcResponse(msg.ID, nil, rerr))
+			continue
+		}
+		s.write(rpcResponse(msg.ID, result, nil))
+	}
+	return s.writeErr
+}
+
+// write sends a message, after the first failure nothing is sent and serve stops.
+func (s *lspServer) write(v any) {
+	if s.writeErr == nil {
+		s.writeErr = writeMessage(s.out, v)
+	}
+}
+
+func (s *lspServer) notify(method string, params any) {
+	s.write(struct {
+		JSONRPC string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		Params  any    `json:"params"`
+	}{"2.0", method, params})
+}
+
+func decodeParams(params json.RawMessage, v any) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+type lspTextDocumentID struct {
+	URI string `json:"uri"`
+}
+
+type lspPositionParams struct {
+	TextDocument lspTextDocumentID `json:"textDocument"`
+	Position     lspPosition       `json:"position"`
+}
+
+// handle runs a request or notification and returns its result.
+func (s *lspServer) handle(method string, params json.RawMessage) (any, error) {
+	switch {
+	case method == "initialize":
+		s.initialized = true
+		return lspInitializeResult(), nil
+	case !s.initialized:
+		return nil, &rpcError{Code: rpcServerNotInitialized, Message: "initialize first"}
+	case s.shutdown:
+		return nil, &rpcError{Code: rpcInvalidRequest, Message: "shutting down"}
+	}
+	switch method {
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, s.open(p.TextDocument.URI, []byte(p.TextDocument.Text))
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument   lspTextDocumentID `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// full sync, the last change is the whole text
+		return nil, s.open(p.TextDocument.URI, []byte(p.ContentChanges[len(p.ContentChanges)-1].Text))
+	case "textDocument/didClose":
+		var p struct {
+			TextDocument lspTextDocumentID `json:"textDocument"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		s.close(p.TextDocument.URI)
+		return nil, nil
+	case "textDocument/hover", "textDocument/definition", "textDocument/completion":
+		var p lspPositionParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		doc := s.docs[p.TextDocument.URI]
+		if doc == nil {
+			return nil, nil
+		}
+		switch method {
+		case "textDocument/hover":
+			return s.hover(doc, p.Position), nil
+		case "textDocument/definition":
+			return s.definition(doc, p.Position), nil
+		default:
+			return s.completion(doc, p.Position), nil
+		}
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: "unsupported method " + method}
+}
+
+func lspInitializeResult() any {
+	type completionOptions struct {
+		TriggerCharacters []string `json:"triggerCharacters"`
+	}
+	type textDocumentSync struct {
+		OpenClose bool `json:"openClose"`
+		// Change 1 sends the whole text on every change
+		Change int `json:"change"`
+	}
+	type capabilities struct {
+		TextDocumentSync   textDocumentSync  `json:"textDocumentSync"`
+		HoverProvider      bool              `json:"hoverProvider"`
+		DefinitionProvider bool              `json:"definitionProvider"`
+		CompletionProvider completionOptions `json:"completionProvider"`
+	}
+	type serverInfo struct {
+		Name string `json:"name"`
+	}
+	return struct {
+		Capabilities capabilities `json:"capabilities"`
+		ServerInfo   serverInfo   `json:"serverInfo"`
+	}{
+		Capabilities: capabilities{
+			TextDocumentSync:   textDocumentSync{OpenClose: true, Change: 1},
+			HoverProvider:      true,
+			DefinitionProvider: true,
+			CompletionProvider: completionOptions{TriggerCharacters: []string{`"`, "'", "."}},
+		},
+		ServerInfo: serverInfo{Name: "delta-string-extractor"},
+	}
+}
+
+// open sets the text of a document, extracts it and publishes the diagnostics of all open documents, as the codes of the document may now conflict with other ones. Documents that are not files are ignored.
+func (s *lspServer) open(uri string, text []byte) error {
+	path, err := uriPath(uri)
+	if err != nil {
+		return nil
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return err
+	}
+	s.docs[uri] = &lspDocument{uri: uri, path: path, text: text}
+	s.extract(path, text)
+	s.publishAll()
+	return nil
+}
+
+// close goes back to the file on disk, the document may have been closed without saving.
+func (s *lspServer) close(uri string) {
+	doc := s.docs[uri]
+	if doc == nil {
+		return
+	}
+	delete(s.docs, uri)
+	root, _ := s.rootOf(doc.path)
+	if data, err := os.ReadFile(doc.path); err == nil && root >= 0 {
+		s.extract(doc.path, data)
+	} else {
+		delete(s.files, doc.path)
+		s.sites = nil
+	}
+	s.notify("textDocument/publishDiagnostics", lspPublishDiagnostics{URI: uri, Diagnostics: []lspDiagnostic{}})
+	s.publishAll()
+}
+
+// codeAt returns the code literal of a translation call at pos.
+func (s *lspServer) codeAt(doc *lspDocument, pos lspPosition) (extractor.CodeLiteral, bool) {
+	off := byteOffset(doc.text, pos)
+	for _, lit := range extractor.CodeLiterals(s.files[doc.path].relPath, doc.text, s.cfg) {
+		if lit.Pos <= off && off < lit.End {
+			return lit, true
+		}
+	}
+	return extractor.CodeLiteral{}, false
+}
+
+type lspMarkup struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type lspHover struct {
+	Contents lspMarkup `json:"contents"`
+	Range    lspRange  `json:"range"`
+}
+
+func (s *lspServer) hover(doc *lspDocument, pos lspPosition) any {
+	lit, ok := s.codeAt(doc, pos)
+	if !ok {
+		return nil
+	}
+	// the message of the document comes first, it may conflict with the other ones
+	var sites, others []lspSite
+	for _, site := range s.codeSites(s.files[doc.path].root)[lit.Code] {
+		if site.path == doc.path {
+			sites = append(sites, site)
+		} else {
+			others = append(others, site)
+		}
+	}
+	sites = append(sites, others...)
+	return lspHover{
+		Contents: lspMarkup{Kind: "markdown", Value: s.hoverMarkdown(lit.Code, sites)},
+		Range:    lspRange{Start: positionAt(doc.text, lit.Pos), End: positionAt(doc.text, lit.End)},
+	}
+}
+
+// hoverMarkdown lists the message of a code and its translation in every locale file. The message of the source language is the one of the first call site, the locale file may not be extracted yet.
+func (s *lspServer) hoverMarkdown(code string, sites []lspSite) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "`%s`", code)
+	if len(sites) == 1 {
+		b.WriteString(", 1 call site")
+	} else {
+		fmt.Fprintf(&b, ", %d call sites", len(sites))
+	}
+	b.WriteString("\n\n")
+	if len(sites) != 0 {
+		e := sites[0].entry
+		if e.Context != "" {
+			fmt.Fprintf(&b, "Context: %s\n\n", markdownEscape(e.Context))
+		}
+		if desc := e.Description(); desc != "" {
+			fmt.Fprintf(&b, "_%s_\n\n", markdownEscape(desc))
+		}
+	}
+	translations := s.locales.load()
+	langs := sortedKeys(translations)
+	if len(sites) != 0 && translations[s.sourceLang] == nil {
+		langs = append([]string{s.sourceLang}, langs...)
+	}
+	for _, lang := range langs {
+		var text string
+		if t, ok := translations[lang][code]; ok {
+			text = formatTranslation(t.Translation)
+		}
+		if lang == s.sourceLang && len(sites) != 0 {
+			e := sites[0].entry
+			if len(e.Msgs) != 0 {
+				text = formatTranslation(e.Msgs)
+			} else {
+				text = formatTranslation(e.Msg)
+			}
+		}
+		if text == "" {
+			text = "_not translated_"
+		}
+		fmt.Fprintf(&b, "- **%s**: %s\n", lang, text)
+	}
+	return b.String()
+}
+
+// translationForms returns the forms of a plural translation of a locale file or of msgs, false if it is not a plural.
+func translationForms(v any) (map[string]string, bool) {
+	switch t := v.(type) {
+	case map[string]string:
+		return t, true
+	case map[string]any:
+		forms := make(map[string]string, len(t))
+		for form, msg := range t {
+			forms[form] = translationText(msg)
+		}
+		return forms, true
+	}
+	return nil, false
+}
+
+// translationText returns a translation that is a string or an array of lines, which createTranslator joins with newlines.
+func translationText(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []any:
+		lines := make([]string, 0, len(t))
+		for _, l := range t {
+			lines = append(lines, translationText(l))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+// formatTranslation returns a translation as markdown, plural forms in the order of extractor.PluralCategories. It is empty if there is no text.
+func formatTranslation(v any) string {
+	forms, ok := translationForms(v)
+	if !ok {
+		return markdownEscape(translationText(v))
+	}
+	var parts []string
+	for _, form := range extractor.PluralCategories {
+		if msg, ok := forms[form]; ok {
+			if msg == "" {
+				parts = append(parts, fmt.Sprintf("*%s*: _empty_", form))
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("*%s*: %s", form, markdownEscape(msg)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+var markdownReplacer = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "\n", " ")
+
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+// definition returns the entries of the code at pos in the output file and the source locale file, followed by its other call sites.
+func (s *lspServer) definition(doc *lspDocument, pos lspPosition) []lspLocation {
+	lit, ok := s.codeAt(doc, pos)
+	if !ok {
+		return nil
+	}
+	root := s.files[doc.path].root
+	var files []string
+	if root >= 0 {
+		files = append(files, s.roots[root].outputFile)
+	}
+	files = append(files, filepath.Join(s.locales.dir, s.sourceLang+".json"))
+	locs := []lspLocation{}
+	seen := make(map[string]bool)
+	for _, f := range files {
+		path, err := filepath.Abs(f)
+		if err != nil || seen[path] {
+			continue
+		}
+		seen[path] = true
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if start, end, ok := locales.FindID(data, lit.Code); ok {
+			locs = append(locs, lspLocation{URI: pathURI(path), Range: lspRange{Start: positionAt(data, start), End: positionAt(data, end)}})
+		}
+	}
+
+	sites := s.codeSites(root)[lit.Code]
+	// own is the call containing the literal, the last one of the document starting before it
+	own, ownPos := -1, -1
+	for i, site := range sites {
+		if site.path != doc.path {
+			continue
+		}
+		_, line, col := site.entry.Position()
+		if p := lineColumnOffset(doc.text, line, col); p <= lit.Pos && p > ownPos {
+			own, ownPos = i, p
+		}
+	}
+	for i, site := range sites {
+		if i == own {
+			continue
+		}
+		text, err := s.text(site.path)
+		if err != nil {
+			continue
+		}
+		_, line, col := site.entry.Position()
+		p := positionAt(text, lineColumnOffset(text, line, col))
+		locs = append(locs, lspLocation{URI: pathURI(site.path), Range: lspRange{Start: p, End: p}})
+	}
+	return locs
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspCompletionItem struct {
+	Label    string      `json:"label"`
+	Kind     int         `json:"kind"`
+	Detail   string      `json:"detail,omitempty"`
+	TextEdit lspTextEdit `json:"textEdit"`
+}
+
+type lspCompletionList struct {
+	IsIncomplete bool                `json:"isIncomplete"`
+	Items        []lspCompletionItem `json:"items"`
+}
+
+// lspCompletionValue is the CompletionItemKind of codes.
+const lspCompletionValue = 12
+
+// codePrefix matches the line up to the cursor inside the string of a code property.
+var codePrefix = regexp.MustCompile(`\bcode\s*:\s*["']([^"'\\]*)$`)
+
+// completion offers the codes of the root inside the string of a code property, with their message as detail.
+func (s *lspServer) completion(doc *lspDocument, pos lspPosition) any {
+	off := byteOffset(doc.text, pos)
+	lineStart := bytes.LastIndexByte(doc.text[:off], '\n') + 1
+	m := codePrefix.FindSubmatchIndex(doc.text[lineStart:off])
+	if m == nil {
+		return nil
+	}
+	typed := string(doc.text[lineStart+m[2] : off])
+	rng := lspRange{Start: positionAt(doc.text, lineStart+m[2]), End: positionAt(doc.text, off)}
+	sites := s.codeSites(s.files[doc.path].root)
+	items := []lspCompletionItem{}
+	for _, code := range sortedKeys(sites) {
+		if code == "" || code == typed && len(sites[code]) == 1 && sites[code][0].path == doc.path {
+			// the code being typed
+			continue
+		}
+		items = append(items, lspCompletionItem{
+			Label:    code,
+			Kind:     lspCompletionValue,
+			Detail:   messageText(sites[code][0].entry),
+			TextEdit: lspTextEdit{Range: rng, NewText: code},
+		})
+	}
+	return lspCompletionList{Items: items}
+}
+
+// Severities of lspDiagnostic.
+const (
+	lspSeverityError   = 1
+	lspSeverityWarning = 2
+)
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspPublishDiagnostics struct {
+	URI         string          `json:"uri"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+}
+
+func (s *lspServer) publishAll() {
+	for _, uri := range sortedKeys(s.docs) {
+		doc := s.docs[uri]
+		var diags []lspDiagnostic
+		for _, d := range s.diagnostics(doc) {
+			diags = append(diags, lspDiagnosticOf(doc.text, d))
+		}
+		if diags == nil {
+			diags = []lspDiagnostic{}
+		}
+		s.notify("textDocument/publishDiagnostics", lspPublishDiagnostics{URI: uri, Diagnostics: diags})
+	}
+}
+
+// diagnostics returns the diagnostics of the extraction of an open document, the conflicts of its codes with other call sites of the root and the plural forms its plural messages miss in the locale files.
+func (s *lspServer) diagnostics(doc *lspDocument) []extractor.Diagnostic {
+	file := s.files[doc.path]
+	diags := append([]extractor.Diagnostic(nil), file.diagnostics...)
+	sites := s.codeSites(file.root)
+	checked := make(map[string]bool)
+	for _, e := range file.entries {
+		if checked[e.Code] {
+			continue
+		}
+		checked[e.Code] = true
+		entries := make([]extractor.Entry, 0, len(sites[e.Code]))
+		for _, site := range sites[e.Code] {
+			entries = append(entries, site.entry)
+		}
+		if !conflicting(entries) {
+			continue
+		}
+		for _, d := range (conflict{Code: e.Code, Entries: entries}).diagnostics(s.cfg) {
+			if d.File == file.relPath {
+				diags = append(diags, d)
+			}
+		}
+	}
+	diags = append(diags, s.pluralDiagnostics(file.entries)...)
+	extractor.SortDiagnostics(diags)
+	return diags
+}
+
+// pluralDiagnostics reports plural messages whose translation in a locale file lacks a form its language uses, see locales.PluralForms, or has it empty. createTranslator falls back to other for a missing form, but not for an empty one. Languages without a translation of the message are not reported.
+func (s *lspServer) pluralDiagnostics(entries []extractor.Entry) []extractor.Diagnostic {
+	sev := s.cfg.Severity(extractor.RuleMissingPluralForm)
+	if sev == extractor.SeverityOff {
+		return nil
+	}
+	var translations map[string]map[string]locales.TranslationEntry
+	var diags []extractor.Diagnostic
+	for _, e := range entries {
+		if len(e.Msgs) == 0 {
+			continue
+		}
+		if translations == nil {
+			translations = s.locales.load()
+		}
+		for _, lang := range sortedKeys(translations) {
+			t, ok := translations[lang][e.Code]
+			if lang == s.sourceLang || !ok {
+				continue
+			}
+			forms, ok := translationForms(t.Translation)
+			if !ok {
+				continue
+			}
+			var missing []string
+			for _, form := range locales.PluralForms(lang) {
+				if forms[form] == "" {
+					missing = append(missing, form)
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			what := "the plural form " + missing[0]
+			if len(missing) > 1 {
+				what = "the plural forms " + strings.Join(missing, ", ")
+			}
+			file, line, col := e.Position()
+			diags = append(diags, extractor.Diagnostic{
+				Rule:     extractor.RuleMissingPluralForm,
+				Severity: sev,
+				File:     file,
+				Line:     line,
+				Column:   col,
+				Message:  fmt.Sprintf("%s translation of %q is missing %s", lang, e.Code, what),
+			})
+		}
+	}
+	return diags
+}
+
+// lspDiagnosticOf converts a diagnostic of text. The range covers the callee or string literal at the position, diagnostics of the whole file are shown at its start.
+func lspDiagnosticOf(text []byte, d extractor.Diagnostic) lspDiagnostic {
+	var rng lspRange
+	if d.Line != 0 {
+		start := lineColumnOffset(text, d.Line, d.Column)
+		rng = lspRange{Start: positionAt(text, start), End: positionAt(text, tokenEnd(text, start))}
+	}
+	sev := lspSeverityError
+	if d.Severity == extractor.SeverityWarning {
+		sev = lspSeverityWarning
+	}
+	return lspDiagnostic{Range: rng, Severity: sev, Code: d.Rule, Source: "delta-string-extractor", Message: d.Message}
+}
+
+// tokenEnd returns the end of the string literal or of the identifiers joined by dots, such as ctx.t, at offset.
+func tokenEnd(text []byte, offset int) int {
+	end := offset
+	if end < len(text) && (text[end] == '"' || text[end] == '\'') {
+		quote := text[end]
+		for end++; end < len(text) && text[end] != '\n'; end++ {
+			if text[end] == '\\' {
+				end++
+				continue
+			}
+			if text[end] == quote {
+				return end + 1
+			}
+		}
+		return min(end, len(text))
+	}
+	for end < len(text) {
+		c := text[end]
+		if c != '.' && c != '_' && c != '$' && !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			break
+		}
+		end++
+	}
+	return end
+}
+
+// localeTranslations are the translations of the locale files of a directory. Files are read again when they change on disk, for example after a translation run.
+type localeTranslations struct {
+	dir   string
+	files map[string]localeFile
+}
+
+type localeFile struct {
+	modTime time.Time
+	size    int64
+	// byID is nil if the file could not be read
+	byID map[string]locales.TranslationEntry
+}
+
+// load returns the translations by language and id. Files that can not be read are reported once and left out.
+func (l *localeTranslations) load() map[string]map[string]locales.TranslationEntry {
+	paths, err := locales.LanguageFiles(l.dir)
+	if err != nil {
+		fmt.Fprintln(logw, err)
+	}
+	res := make(map[string]map[string]locales.TranslationEntry, len(paths))
+	for lang, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		f, ok := l.files[lang]
+		if !ok || !f.modTime.Equal(info.ModTime()) || f.size != info.Size() {
+			f = localeFile{modTime: info.ModTime(), size: info.Size()}
+			entries, err := locales.ReadTranslations(path)
+			if err != nil {
+				fmt.Fprintln(logw, err)
+			} else {
+				f.byID = make(map[string]locales.TranslationEntry, len(entries))
+				for _, e := range entries {
+					f.byID[e.ID] = e
+				}
+			}
+			l.files[lang] = f
+		}
+		if f.byID != nil {
+			res[lang] = f.byID
+		}
+	}
+	return res
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestMessageFraming(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeMessage(&b, map[string]string{"msg": "Zatvori ✓"}); err != nil {
+		t.Fatal(err)
+	}
+	b.WriteString("content-length: 2\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n{}")
+	r := bufio.NewReader(&b)
+	for _, want := range []string{`{"msg":"Zatvori ✓"}`, `{}`} {
+		data, err := readMessage(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("wanted %s, got %s", want, data)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	text := []byte("a😀b\r\nčd")
+	cases := []struct {
+		pos    lspPosition
+		offset int
+	}{
+		{lspPosition{0, 0}, 0},
+		{lspPosition{0, 1}, 1},
+		// the emoji is two UTF-16 code units
+		{lspPosition{0, 3}, 5},
+		{lspPosition{1, 0}, 8},
+		{lspPosition{1, 1}, 10},
+	}
+	for _, c := range cases {
+		if got := byteOffset(text, c.pos); got != c.offset {
+			t.Errorf("byteOffset %v: wanted %d, got %d", c.pos, c.offset, got)
+		}
+		if got := positionAt(text, c.offset); got != c.pos {
+			t.Errorf("positionAt %d: wanted %v, got %v", c.offset, c.pos, got)
+		}
+	}
+	if got := byteOffset(text, lspPosition{0, 99}); got != 7 {
+		t.Errorf("wanted the end of the line, got %d", got)
+	}
+	if got := lineColumnOffset(text, 2, 2); got != 10 {
+		t.Errorf("lineColumnOffset: wanted 10, got %d", got)
+	}
+}
+
+func TestLSPSession(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("app/a.tsx", `ctx.t({ code: "common.save", msg: "Save" });
+ctx.t({ code: "items.count", msgs: { one: "{n} item", other: "{n} items" } }, { n });
+`)
+	b := write("app/b.tsx", "")
+	write("locales/en.json", `[
+	{
+		"id": "common.save",
+		"translation": "Save"
+	}
+]
+`)
+	write("locales/fr.json", `[
+	{ "id": "common.save", "translation": "Enregistrer" },
+	{ "id": "items.count", "translation": { "one": "{n} élément", "other": "" } }
+]
+`)
+
+	r := root{dir: filepath.Join(dir, "app"), outputFile: filepath.Join(dir, "locales", "en.json"), filter: fileFilter{}.withDefaults()}
+	s, err := newLSPServer(extractor.DefaultConfig(), []root{r}, filepath.Join(dir, "locales"), "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.index(loadFileCache("", ""), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	uri := pathURI(b)
+	text := `ctx.t({ code: "common.save", msg: "Store" });
+ctx.t({ code: "items.count", msgs: { one: "{n} item", other: "{n} items" } }, { n });
+ctx.t({ code: "co`
+	var in bytes.Buffer
+	send := func(id int, method string, params any) {
+		msg := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+		if id != 0 {
+			msg["id"] = id
+		}
+		if err := writeMessage(&in, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	position := func(line, character int) any {
+		return map[string]any{"textDocument": map[string]any{"uri": uri}, "position": lspPosition{line, character}}
+	}
+	send(1, "initialize", map[string]any{})
+	send(0, "textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "text": text}})
+	send(2, "textDocument/hover", position(0, 17))
+	send(3, "textDocument/definition", position(0, 17))
+	send(4, "textDocument/completion", position(2, 17))
+	send(5, "shutdown", nil)
+	send(0, "exit", nil)
+	var out bytes.Buffer
+	if err := s.serve(&in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	type message struct {
+		ID     int             `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		Result json.RawMessage `json:"result"`
+	}
+	var messages []message
+	outr := bufio.NewReader(&out)
+	for {
+		data, err := readMessage(outr)
+		if err != nil {
+			break
+		}
+		var m message
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, m)
+	}
+	results := make(map[int]string)
+	var diagnostics lspPublishDiagnostics
+	for _, m := range messages {
+		if m.Method == "textDocument/publishDiagnostics" {
+			if err := json.Unmarshal(m.Params, &diagnostics); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		results[m.ID] = string(m.Result)
+	}
+
+	var rules []string
+	for _, d := range diagnostics.Diagnostics {
+		rules = append(rules, d.Code+": "+d.Message)
+	}
+	got := strings.Join(rules, "\n")
+	for _, want := range []string{
+		`conflicting-message: code "common.save" has conflicting messages: "Store" here, "Save" at a.tsx:1`,
+		`missing-plural-form: fr translation of "items.count" is missing the plural forms many, other`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("wanted diagnostic %s, got\n%s", want, got)
+		}
+	}
+	for _, want := range []string{`**en**: Store`, `**fr**: Enregistrer`, "2 call sites"} {
+		if !strings.Contains(results[2], want) {
+			t.Errorf("wanted %s in hover, got %s", want, results[2])
+		}
+	}
+	for _, want := range []string{pathURI(filepath.Join(dir, "locales", "en.json")), pathURI(filepath.Join(dir, "app", "a.tsx"))} {
+		if !strings.Contains(results[3], want) {
+			t.Errorf("wanted %s in definition, got %s", want, results[3])
+		}
+	}
+	if strings.Contains(results[3], uri) {
+		t.Errorf("wanted no location in the document itself, got %s", results[3])
+	}
+	if !strings.Contains(results[4], `"label":"common.save"`) || strings.Contains(results[4], `"label":"co"`) {
+		t.Errorf("wanted the existing codes as completion, got %s", results[4])
+	}
+}
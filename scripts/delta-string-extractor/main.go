@@ -1,95 +1,221 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"time"
 
 	"delta-string-extractor/extractor"
 )
 
-// Configuration: allowed extensions and ignored directories
-var (
-	allowedExtensions = []string{".ts", ".tsx"}
-	ignoredDirs       = []string{"node_modules"}
-)
+// commands are run with the first argument, for example delta-string-extractor unused --prune. Without a command the strings are extracted.
+var commands = map[string]func(args []string) error{
+	"unused":     runUnused,
+	"compare-ts": runCompareTS,
+	"hardcoded":  runHardcoded,
+	"wrap":       runWrap,
+	"rename-key": runRenameKey,
+	"lsp":        runLSP,
+}
 
 func main() {
-	dir := flag.String("dir", "../../app", "directory to scan for files")
-	outputFile := flag.String("output-file", filepath.FromSlash("../../app/locales/app/en.json"), "output file path")
-	flag.Parse()
+	run := runExtract
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			run, args = cmd, args[1:]
+		}
+	}
+	if err := run(args); err != nil {
+		fmt.Fprintln(logw, "Failed:")
+		fmt.Fprintln(logw, err)
+		os.Exit(1)
+	}
+}
 
-	var entries []extractor.Entry
-	var hadError bool
+// errDiagnostics is returned when extraction found problems with error severity, they are already reported as diagnostics.
+var errDiagnostics = errors.New("extraction found errors, see the diagnostics")
+
+func runExtract(args []string) error {
+	fs := flag.NewFlagSet("delta-string-extractor", flag.ExitOnError)
+	ef := registerExtractFlags(fs)
+	outputFile := fs.String("output-file", filepath.FromSlash("../../app/locales/app/en.json"), "output file path")
+	check := fs.Bool("check", false, "do not write the output file, exit with an error if it is out of date or messages conflict")
+	watchMode := fs.Bool("watch", false, "keep running and re-extract when files change")
+	watchInterval := fs.Duration("watch-interval", 500*time.Millisecond, "how often to check for changed files in --watch mode")
+	usageFile := fs.String("usage-file", "", "optional file to write the usage index to, listing all call sites of every code")
+	typesFile := fs.String("types-file", "", "optional .d.ts file to write the codes and their placeholders to, for type-checking translation calls")
+	descLocations := fs.Int("desc-locations", 0, "number of call sites to list in descriptions, 0 for only one")
+	compat := fs.String("compat", "", "write the output file like another extractor, ts for scripts/extractor-i18n.ts")
+	generate := fs.String("generate-codes", "", "generate codes for calls without one from the namespace of the file and a hash or slug of the message, instead of reporting missing-code")
+	insert := fs.Bool("insert-codes", false, "with --generate-codes, also insert the generated codes into the source files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateCompat(*compat); err != nil {
+		return err
+	}
+	if err := validateGenerateCodes(*generate); err != nil {
+		return err
+	}
+	if *insert && (*generate == "" || *watchMode || *check) {
+		return errors.New("--insert-codes requires --generate-codes and does not work with --watch or --check")
+	}
+	opts := outputOptions{descLocations: *descLocations, compat: *compat, generateCodes: *generate}
+
+	cfg, err := ef.config()
+	if err != nil {
+		return err
+	}
+	cfg.GenerateCodes = *generate != ""
+	cfg.TSCompat = *compat == compatTS
 
-	files := 0
+	roots := ef.roots(root{outputFile: *outputFile, usageFile: *usageFile, typesFile: *typesFile})
+	if *watchMode {
+		return watch(roots, opts, cfg, *ef.cacheFile, *ef.workers, *watchInterval)
+	}
 
-	err := filepath.Walk(*dir, func(path string, info os.FileInfo, err error) error {
+	cache := loadFileCache(*ef.cacheFile, cacheFingerprint(cfg))
+	var all []extractor.Entry
+	var diags []extractor.Diagnostic
+	outputs := make([][]byte, len(roots))
+	files, generated, changedFiles := 0, 0, 0
+	for i, r := range roots {
+		out, err := extractOutput(r, cfg, cache, *ef.workers, opts)
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
-			if inArray(ignoredDirs, info.Name()) {
-				return filepath.SkipDir
+		files += out.files
+		diags = append(diags, rootDiagnostics(roots, r, out.diagnostics)...)
+		outputs[i] = out.data
+		generated += len(out.generated)
+
+		if *insert {
+			n, err := insertCodes(r, out.generated)
+			if err != nil {
+				return err
 			}
-			return nil
+			changedFiles += n
 		}
 
-		ext := filepath.Ext(path)
-		if !inArray(allowedExtensions, ext) {
-			return nil
+		if r.usageFile != "" && !*check {
+			if err := writeUsageIndex(r.usageFile, out.entries); err != nil {
+				return err
+			}
 		}
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return err
+		if r.typesFile != "" && !*check {
+			if err := writeAtomically(r.typesFile, renderTypes(out.entries)); err != nil {
+				return err
+			}
 		}
-		relPath, err := filepath.Rel(*dir, path)
+		all = append(all, out.entries...)
+	}
+	if err := cache.save(); err != nil {
+		fmt.Fprintln(logw, "failed to save cache", err)
+	}
+	if err := ef.writeDiagnostics(diagnosticsDir(roots), diags); err != nil {
+		return err
+	}
+
+	var outOfDate []string
+	for i, r := range roots {
+		if !*check {
+			if err := writeAtomically(r.outputFile, outputs[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		upToDate, err := checkOutputFile(r.outputFile, outputs[i])
 		if err != nil {
 			return err
 		}
-		parts, err := extractor.ExtractFromContent(relPath, data)
-		if err != nil {
-			fmt.Println("error processing", relPath)
-			fmt.Println("error", err)
-			hadError = true
-			return nil
+		if !upToDate {
+			outOfDate = append(outOfDate, r.outputFile)
 		}
-		files++
-		for _, p := range parts {
-			if p.Code == "" {
-				return fmt.Errorf("missing Code in translation part: file=%s, part=%+v", relPath, p)
-			}
+	}
 
-			if len(p.Msgs) > 0 {
-				// If Msgs is provided (even if empty map), we expect content
-				// But it might have no entries, so check that
-				hasMsgs := false
-				for _, msg := range p.Msgs {
-					if msg != "" {
-						hasMsgs = true
-						break
-					}
-				}
-				if !hasMsgs && p.Msg == "" {
-					return fmt.Errorf("missing translation: 'Msg' is empty and 'Msgs' has no non-empty entries: file=%s, part=%+v", relPath, p)
-				}
-			} else {
-				// No Msgs, so Msg must be non-empty
-				if p.Msg == "" {
-					return fmt.Errorf("missing Msg and Msgs in translation part: file=%s, part=%+v", relPath, p)
-				}
-			}
+	fmt.Fprintln(logw, "Files processed", files)
+	fmt.Fprintln(logw, "Strings for translation found", len(all))
+	if *generate != "" {
+		fmt.Fprintf(logw, "Codes generated %d, inserted into %d files\n", generated, changedFiles)
+	}
+	printCalleeCounts(all)
+	if extractor.HasErrors(diags) {
+		return errDiagnostics
+	}
+	if len(outOfDate) != 0 {
+		return fmt.Errorf("%s out of date", strings.Join(outOfDate, ", "))
+	}
+	return nil
+}
+
+// rootOutput is the extraction result of a root.
+type rootOutput struct {
+	files   int
+	entries []extractor.Entry
+	// generated are the entries with codes generated by opts.generateCodes
+	generated []extractor.Entry
+	// diagnostics include conflicts, paths are relative to the root
+	diagnostics []extractor.Diagnostic
+	// data is the content of the output file
+	data []byte
+}
+
+// extractOutput extracts all files of a root and renders its output file.
+func extractOutput(r root, cfg extractor.Config, cache *fileCache, workers int, opts outputOptions) (rootOutput, error) {
+	results, err := extractRoot(r, cfg, cache, workers)
+	if err != nil {
+		return rootOutput{}, err
+	}
+	out := rootOutput{files: len(results)}
+	var tsEntries []extractor.Entry
+	for _, res := range results {
+		out.diagnostics = append(out.diagnostics, res.diagnostics...)
+		out.entries = append(out.entries, res.entries...)
+		tsEntries = append(tsEntries, res.tsEntries...)
+	}
+	if opts.generateCodes != "" {
+		if out.generated, err = generateCodes(out.entries, r.outputFile, opts.generateCodes); err != nil {
+			return rootOutput{}, err
 		}
+	}
+	sortEntries(out.entries)
+	out.diagnostics = append(out.diagnostics, extractor.CheckNearDuplicates(out.entries, cfg)...)
 
-		entries = append(entries, parts...)
-		return nil
-	})
+	data, conflicts, err := renderOutput(out.entries, tsEntries, opts.forRoot(r))
 	if err != nil {
-		panic(err)
+		return rootOutput{}, err
+	}
+	out.diagnostics = append(out.diagnostics, conflictDiagnostics(conflicts, cfg)...)
+	out.data = data
+	return out, nil
+}
+
+// diagnosticsDir returns the directory diagnostic paths are relative to. With a single root it is the root, otherwise the paths include the root directory, see rootDiagnostics.
+func diagnosticsDir(roots []root) string {
+	if len(roots) == 1 {
+		return roots[0].dir
+	}
+	return "."
+}
+
+// rootDiagnostics makes the paths of the diagnostics of root r relative to diagnosticsDir.
+func rootDiagnostics(roots []root, r root, diags []extractor.Diagnostic) []extractor.Diagnostic {
+	if len(roots) == 1 {
+		return diags
 	}
+	for i := range diags {
+		diags[i].File = filepath.Join(r.dir, diags[i].File)
+	}
+	return diags
+}
 
+func sortEntries(entries []extractor.Entry) {
 	sort.Slice(entries, func(i, j int) bool {
 		a, b := entries[i], entries[j]
 		if a.Code == b.Code {
@@ -97,16 +223,21 @@ func main() {
 		}
 		return a.Code < b.Code
 	})
+}
 
-	err = writeEntriesJSON(*outputFile, entries)
-	if err != nil {
-		panic(err)
+// printCalleeCounts reports how many strings each callee form produced, for example to tell server-side backendCtx.t usage from client-side ctx.t usage.
+func printCalleeCounts(entries []extractor.Entry) {
+	counts := map[string]int{}
+	for _, e := range entries {
+		counts[e.Callee]++
 	}
-
-	fmt.Println("Files processed", files)
-	fmt.Println("Strings for translation found", len(entries))
-	if hadError {
-		os.Exit(1)
+	callees := make([]string, 0, len(counts))
+	for c := range counts {
+		callees = append(callees, c)
+	}
+	sort.Strings(callees)
+	for _, c := range callees {
+		fmt.Fprintf(logw, "  %s: %d\n", c, counts[c])
 	}
 }
 
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// edit replaces src[pos:end] with text.
+type edit struct {
+	pos, end int
+	text     string
+}
+
+// applyEdits returns src with the edits applied. Edits must not overlap.
+func applyEdits(src []byte, edits []edit) []byte {
+	sorted := make([]edit, len(edits))
+	copy(sorted, edits)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].pos < sorted[j].pos })
+	var b bytes.Buffer
+	last := 0
+	for _, e := range sorted {
+		b.Write(src[last:e.pos])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.Write(src[last:])
+	return b.Bytes()
+}
+
+// diffContext is the number of unchanged lines around changes in patches.
+const diffContext = 3
+
+// unifiedDiff returns a patch of the edits to src in unified format, which git apply and patch -p1 accept. name is the path of the file relative to the repository root.
+func unifiedDiff(name string, src []byte, edits []edit) string {
+	if len(edits) == 0 {
+		return ""
+	}
+	lines := splitLines(src)
+	starts := make([]int, len(lines)+1)
+	for i, l := range lines {
+		starts[i+1] = starts[i] + len(l)
+	}
+	lineOf := func(offset int) int {
+		return sort.Search(len(lines), func(i int) bool { return starts[i+1] > offset })
+	}
+
+	// a block is a range of lines changed by one or more edits
+	type block struct {
+		first, last int
+		edits       []edit
+	}
+	sorted := make([]edit, len(edits))
+	copy(sorted, edits)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].pos < sorted[j].pos })
+	var blocks []block
+	for _, e := range sorted {
+		first, last := lineOf(e.pos), lineOf(max(e.pos, e.end-1))
+		if n := len(blocks); n > 0 && first <= blocks[n-1].last {
+			blocks[n-1].last = max(blocks[n-1].last, last)
+			blocks[n-1].edits = append(blocks[n-1].edits, e)
+			continue
+		}
+		blocks = append(blocks, block{first: first, last: last, edits: []edit{e}})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", name, name)
+	delta := 0
+	for i := 0; i < len(blocks); {
+		// blocks whose context overlaps go into one hunk
+		j := i + 1
+		for j < len(blocks) && blocks[j].first-blocks[j-1].last-1 <= 2*diffContext {
+			j++
+		}
+		from := max(0, blocks[i].first-diffContext)
+		to := min(len(lines)-1, blocks[j-1].last+diffContext)
+
+		var body strings.Builder
+		oldLen, newLen := 0, 0
+		context := func(from, to int) {
+			for l := from; l <= to; l++ {
+				body.WriteString(lineWithPrefix(' ', lines[l]))
+				oldLen++
+				newLen++
+			}
+		}
+		next := from
+		for _, bl := range blocks[i:j] {
+			context(next, bl.first-1)
+			old := src[starts[bl.first]:starts[bl.last+1]]
+			shifted := make([]edit, len(bl.edits))
+			for k, e := range bl.edits {
+				shifted[k] = edit{pos: e.pos - starts[bl.first], end: e.end - starts[bl.first], text: e.text}
+			}
+			for _, l := range splitLines(old) {
+				oldLen++
+				body.WriteString(lineWithPrefix('-', l))
+			}
+			for _, l := range splitLines(applyEdits(old, shifted)) {
+				newLen++
+				body.WriteString(lineWithPrefix('+', l))
+			}
+			next = bl.last + 1
+		}
+		context(next, to)
+
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", from+1, oldLen, from+1+delta, newLen)
+		b.WriteString(body.String())
+		delta += newLen - oldLen
+		i = j
+	}
+	return b.String()
+}
+
+// lineWithPrefix returns a line of a patch, marking a missing newline at the end of the file.
+func lineWithPrefix(prefix byte, line string) string {
+	if strings.HasSuffix(line, "\n") {
+		return string(prefix) + line
+	}
+	return string(prefix) + line + "\n\\ No newline at end of file\n"
+}
+
+// splitLines splits data into lines, each with its newline.
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			lines = append(lines, string(data))
+			break
+		}
+		lines = append(lines, string(data[:i+1]))
+		data = data[i+1:]
+	}
+	return lines
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	src := "1\n2\n3\n4 x\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14 y\n15"
+	edits := []edit{
+		{pos: 8, end: 9, text: "{X}"},
+		{pos: 35, end: 36, text: "{Y}\nnew"},
+	}
+	if want, got := "1\n2\n3\n4 {X}\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14 {Y}\nnew\n15", string(applyEdits([]byte(src), edits)); want != got {
+		t.Errorf("applyEdits: wanted %q, got %q", want, got)
+	}
+
+	want := `--- a/app/a.tsx
++++ b/app/a.tsx
+@@ -1,7 +1,7 @@
+ 1
+ 2
+ 3
+-4 x
++4 {X}
+ 5
+ 6
+ 7
+@@ -11,5 +11,6 @@
+ 11
+ 12
+ 13
+-14 y
++14 {Y}
++new
+ 15
+\ No newline at end of file
+`
+	if got := unifiedDiff("app/a.tsx", []byte(src), edits); got != want {
+		t.Errorf("unifiedDiff: wanted\n%s\ngot\n%s", want, got)
+	}
+
+	// changes closer than twice the context share a hunk
+	edits = []edit{{pos: 2, end: 3, text: "two"}, {pos: 8, end: 9, text: "four"}}
+	want = `--- a/a.ts
++++ b/a.ts
+@@ -1,7 +1,7 @@
+ 1
+-2
++two
+ 3
+-4 x
++4 four
+ 5
+ 6
+ 7
+`
+	if got := unifiedDiff("a.ts", []byte(src), edits); got != want {
+		t.Errorf("unifiedDiff: wanted\n%s\ngot\n%s", want, got)
+	}
+}
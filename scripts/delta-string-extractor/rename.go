@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"delta-string-extractor/extractor"
+	"delta-string-extractor/locales"
+)
+
+// keyRename maps codes to their new names: a single code, all codes with a prefix or all codes matching a regular expression.
+type keyRename struct {
+	from, to string
+	prefix   bool
+	re       *regexp.Regexp
+}
+
+func newKeyRename(from, to string, prefix, regex bool) (keyRename, error) {
+	k := keyRename{from: from, to: to, prefix: prefix}
+	if prefix && regex {
+		return k, errors.New("use either --prefix or --regex")
+	}
+	if from == "" || to == "" && !regex {
+		return k, errors.New("old and new code must not be empty")
+	}
+	if regex {
+		re, err := regexp.Compile("^(?:" + from + ")$")
+		if err != nil {
+			return k, fmt.Errorf("invalid --regex %q: %w", from, err)
+		}
+		k.re = re
+	}
+	return k, nil
+}
+
+// rename returns the new name of code, false if the code is not renamed.
+func (k keyRename) rename(code string) (string, bool) {
+	var res string
+	switch {
+	case k.re != nil:
+		if !k.re.MatchString(code) {
+			return "", false
+		}
+		res = k.re.ReplaceAllString(code, k.to)
+	case k.prefix:
+		if !strings.HasPrefix(code, k.from) {
+			return "", false
+		}
+		res = k.to + code[len(k.from):]
+	default:
+		res = k.to
+		if code != k.from {
+			return "", false
+		}
+	}
+	return res, res != code
+}
+
+// keyRenames collects the renamed codes of all files and checks that they do not collide.
+type keyRenames struct {
+	keyRename
+	// renamed maps old codes to new ones
+	renamed map[string]string
+	// existing are all codes found in sources and locale files
+	existing map[string]bool
+}
+
+// code returns the new name of code and records it, code itself if it is not renamed.
+func (k *keyRenames) code(code string) string {
+	k.existing[code] = true
+	to, ok := k.rename(code)
+	if !ok {
+		return code
+	}
+	k.renamed[code] = to
+	return to
+}
+
+// check reports new codes that already exist or that several codes are renamed to.
+func (k *keyRenames) check() error {
+	var problems []string
+	from := make(map[string]string)
+	for _, old := range sortedKeys(k.renamed) {
+		to := k.renamed[old]
+		if to == "" {
+			problems = append(problems, fmt.Sprintf("%s would be renamed to an empty code", old))
+		}
+		if _, renamed := k.renamed[to]; k.existing[to] && !renamed {
+			problems = append(problems, fmt.Sprintf("%s can not be renamed to %s, it already exists", old, to))
+		}
+		if other, ok := from[to]; ok {
+			problems = append(problems, fmt.Sprintf("%s and %s would both be renamed to %s", other, old, to))
+		}
+		from[to] = old
+	}
+	if len(problems) != 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// runRenameKey renames codes in the translation calls of the sources, the output file and all locale files. All files are written together, or none if the codes collide.
+func runRenameKey(args []string) error {
+	fs := flag.NewFlagSet("rename-key", flag.ExitOnError)
+	ef := registerExtractFlags(fs)
+	outputFile := fs.String("output-file", filepath.FromSlash("../../app/locales/app/en.json"), "output file path, regenerated with the new codes")
+	localesDir := fs.String("locales-dir", filepath.FromSlash("../../locales"), "directory with a directory of <lang>.json locale files per subdir")
+	subdirs := CommaSeparated{"app", "content"}
+	fs.Var(&subdirs, "subdirs", "Comma-separated subdirectories of --locales-dir to rename codes in (default app,content)")
+	var testDirs CommaSeparated
+	fs.Var(&testDirs, "test-dirs", "Comma-separated directories with tests to rename codes in as well (default ../../tests)")
+	prefix := fs.Bool("prefix", false, "rename all codes starting with old, for example hazard. to hazards.")
+	regex := fs.Bool("regex", false, "rename all codes matching the regular expression old, new can refer to groups as in ${1}")
+	dryRun := fs.Bool("dry-run", false, "only list the renamed codes and changed files")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: delta-string-extractor rename-key [flags] old new")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("rename-key needs the old and new code")
+	}
+	if len(testDirs) == 0 {
+		testDirs = CommaSeparated{filepath.FromSlash("../../tests")}
+	}
+	k, err := newKeyRename(fs.Arg(0), fs.Arg(1), *prefix, *regex)
+	if err != nil {
+		return err
+	}
+	renames := &keyRenames{keyRename: k, renamed: make(map[string]string), existing: make(map[string]bool)}
+
+	cfg, err := ef.config()
+	if err != nil {
+		return err
+	}
+	roots := ef.roots(root{outputFile: *outputFile})
+	cache := loadFileCache(*ef.cacheFile, cacheFingerprint(cfg))
+	// files are the new contents of all changed files
+	files := make(map[string][]byte)
+	outputFiles := make(map[string]bool)
+
+	// renameSources rewrites the calls of a root and returns its entries with the new codes, renamed is false if no call changed
+	renameSources := func(r root) (entries []extractor.Entry, renamed bool, err error) {
+		results, err := extractRoot(r, cfg, cache, *ef.workers)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, res := range results {
+			path := filepath.Join(r.dir, res.relPath)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				// already reported as read-error by the extraction
+				continue
+			}
+			var edits []edit
+			for _, lit := range extractor.CodeLiterals(res.relPath, data, cfg) {
+				if to := renames.code(lit.Code); to != lit.Code {
+					edits = append(edits, edit{pos: lit.Pos, end: lit.End, text: quoteLike(string(data[lit.Pos:lit.End]), to)})
+				}
+			}
+			if len(edits) != 0 {
+				files[path] = applyEdits(data, edits)
+				renamed = true
+			}
+			for _, e := range res.entries {
+				e.Code = renames.code(e.Code)
+				entries = append(entries, e)
+			}
+		}
+		return entries, renamed, nil
+	}
+	for _, r := range roots {
+		entries, renamed, err := renameSources(r)
+		if err != nil {
+			return err
+		}
+		abs, _ := filepath.Abs(r.outputFile)
+		outputFiles[abs] = true
+		if !renamed {
+			continue
+		}
+		sortEntries(entries)
+		data, _, err := renderEntriesJSON(entries, outputOptions{}.forRoot(r))
+		if err != nil {
+			return err
+		}
+		files[r.outputFile] = data
+	}
+	for _, dir := range testDirs {
+		// same as for unused, tests use the extensions of the roots
+		r := root{dir: dir, filter: fileFilter{Extensions: roots[0].filter.Extensions}.withDefaults()}
+		if _, _, err := renameSources(r); err != nil {
+			return err
+		}
+	}
+
+	for _, subdir := range subdirs {
+		langFiles, err := locales.LanguageFiles(filepath.Join(*localesDir, subdir))
+		if err != nil {
+			return err
+		}
+		for _, lang := range locales.Languages(langFiles) {
+			path := langFiles[lang]
+			if abs, _ := filepath.Abs(path); outputFiles[abs] {
+				// regenerated above
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			data, n, err := locales.RenameIDs(path, data, renames.code)
+			if err != nil {
+				return err
+			}
+			if n != 0 {
+				files[path] = data
+			}
+		}
+	}
+	if err := cache.save(); err != nil {
+		fmt.Fprintln(logw, "failed to save cache", err)
+	}
+
+	if len(renames.renamed) == 0 {
+		return fmt.Errorf("no code matches %s", k.from)
+	}
+	if err := renames.check(); err != nil {
+		return err
+	}
+	for _, old := range sortedKeys(renames.renamed) {
+		fmt.Fprintf(logw, "  %s -> %s\n", old, renames.renamed[old])
+	}
+	for _, path := range sortedKeys(files) {
+		fmt.Fprintln(logw, "  changed", path)
+	}
+	if *dryRun {
+		fmt.Fprintf(logw, "Would rename %d codes in %d files\n", len(renames.renamed), len(files))
+		return nil
+	}
+	if err := writeFilesAtomically(files); err != nil {
+		return err
+	}
+	fmt.Fprintf(logw, "Renamed %d codes in %d files\n", len(renames.renamed), len(files))
+	return nil
+}
+
+// quoteLike returns s as a JS string literal with the quotes of literal.
+func quoteLike(literal, s string) string {
+	if strings.HasPrefix(literal, "'") && !strings.ContainsAny(s, `'\`) {
+		return "'" + s + "'"
+	}
+	return jsString(s)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"delta-string-extractor/locales"
+)
+
+func TestKeyRename(t *testing.T) {
+	cases := []struct {
+		from, to      string
+		prefix, regex bool
+		code, want    string
+	}{
+		{"common.save", "common.save_draft", false, false, "common.save", "common.save_draft"},
+		{"common.save", "common.save_draft", false, false, "common.saved", ""},
+		{"hazard.", "hazards.", true, false, "hazard.title", "hazards.title"},
+		{"hazard.", "hazards.", true, false, "hazardous.title", ""},
+		{`analysis\.(.*)_icon`, "icons.$1", false, true, "analysis.people_icon", "icons.people"},
+		{`analysis\.(.*)_icon`, "icons.$1", false, true, "analysis.people_icon_alt", ""},
+	}
+	for _, c := range cases {
+		k, err := newKeyRename(c.from, c.to, c.prefix, c.regex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := k.rename(c.code); got != c.want {
+			t.Errorf("rename %s to %s: %q wanted %q, got %q", c.from, c.to, c.code, c.want, got)
+		}
+	}
+}
+
+func TestKeyRenamesCheck(t *testing.T) {
+	k, _ := newKeyRename("a.", "b.", true, false)
+	renames := &keyRenames{keyRename: k, renamed: map[string]string{}, existing: map[string]bool{}}
+	for _, code := range []string{"a.x", "a.y", "b.y", "b.z"} {
+		renames.code(code)
+	}
+	if err := renames.check(); err == nil || !strings.Contains(err.Error(), "a.y can not be renamed to b.y") || strings.Contains(err.Error(), "a.x") {
+		t.Errorf("wanted only a.y to collide, got %v", err)
+	}
+
+	// codes of two namespaces moved into one
+	k, _ = newKeyRename(`(a|b)\.(.*)`, "c.$2", false, true)
+	renames = &keyRenames{keyRename: k, renamed: map[string]string{}, existing: map[string]bool{}}
+	renames.code("a.x")
+	renames.code("b.x")
+	if err := renames.check(); err == nil || !strings.Contains(err.Error(), "a.x and b.x would both be renamed to c.x") {
+		t.Errorf("wanted a.x and b.x to collide, got %v", err)
+	}
+}
+
+func TestRenameIDs(t *testing.T) {
+	in := "[\n\t{\n\t\t\"id\": \"a.x\",\n\t\t\"description\": \"\",\n\t\t\"translation\": {\n\t\t\t\"other\": \"X <b>\",\n\t\t\t\"one\": \"x\"\n\t\t}\n\t},\n\t{\n\t\t\"id\": \"b.y\",\n\t\t\"translation\": \"id: \\\"a.x\\\"\"\n\t}\n]\n"
+	want := strings.Replace(in, `"id": "a.x"`, `"id": "c.x"`, 1)
+	got, n, err := locales.RenameIDs("fr.json", []byte(in), func(id string) string {
+		return strings.Replace(id, "a.", "c.", 1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || string(got) != want {
+		t.Errorf("wanted 1 renamed id and\n%s\ngot %d and\n%s", want, n, got)
+	}
+
+	// files of locales/content are indented with spaces, the rest is kept as it is
+	in = "[\n    {\n        \"id\": \"a.x\",\n        \"translation\": \"X\"\n    },\n\n    {\"id\":\"b.y\",\"translation\":\"Y\"}\n]"
+	want = strings.Replace(in, `"id": "a.x"`, `"id": "c.x"`, 1)
+	got, n, err = locales.RenameIDs("fr.json", []byte(in), func(id string) string {
+		return strings.Replace(id, "a.", "c.", 1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || string(got) != want {
+		t.Errorf("wanted 1 renamed id and\n%s\ngot %d and\n%s", want, n, got)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"delta-string-extractor/extractor"
+)
+
+// listFiles returns the files under dir selected by filter. The order is the lexical walk order.
+func listFiles(dir string, filter fileFilter) ([]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ignore *gitignore
+	if *filter.Gitignore {
+		if ignore, err = newGitignore(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var paths []string
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if info.Name() == ".git" || matchAnyGlob(filter.Exclude, rel) || ignore != nil && ignore.ignored(filepath.Join(absDir, rel), true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !inArray(filter.Extensions, filepath.Ext(path)) {
+			return nil
+		}
+		if len(filter.Include) != 0 && !matchAnyGlob(filter.Include, rel) {
+			return nil
+		}
+		if matchAnyGlob(filter.Exclude, rel) || ignore != nil && ignore.ignored(filepath.Join(absDir, rel), false) {
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	return paths, err
+}
+
+func matchAnyGlob(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractRoot extracts all files of a root, using and updating the cache. The cache is saved by the caller.
+func extractRoot(r root, cfg extractor.Config, cache *fileCache, workers int) ([]fileResult, error) {
+	paths, err := listFiles(r.dir, r.filter)
+	if err != nil {
+		return nil, err
+	}
+	return extractFiles(r.dir, paths, cfg, cache, workers), nil
+}
+
+type fileResult struct {
+	relPath     string
+	entries     []extractor.Entry
+	diagnostics []extractor.Diagnostic
+	// tsEntries are only set with extractor.Config.TSCompat
+	tsEntries []extractor.Entry
+}
+
+// extractFiles extracts all files with a bounded pool of workers. Results are returned in the order of paths regardless of which worker finished first, so the output is deterministic.
+func extractFiles(dir string, paths []string, cfg extractor.Config, cache *fileCache, workers int) []fileResult {
+	if workers < 1 {
+		workers = 1
+	}
+	if absDir, err := filepath.Abs(dir); err == nil {
+		cache.scan(absDir)
+	}
+	results := make([]fileResult, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = extractFile(dir, paths[i], cfg, cache)
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// extractFile extracts a single file. A file that can not be read is reported as a diagnostic, so it does not stop the extraction of the other files.
+func extractFile(dir, path string, cfg extractor.Config, cache *fileCache) (res fileResult) {
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil {
+		relPath = path
+	}
+	res.relPath = relPath
+	fail := func(err error) fileResult {
+		if sev := cfg.Severity(extractor.RuleReadError); sev != extractor.SeverityOff {
+			res.diagnostics = []extractor.Diagnostic{{
+				Rule:     extractor.RuleReadError,
+				Severity: sev,
+				File:     relPath,
+				Message:  err.Error(),
+			}}
+		}
+		return res
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fail(err)
+	}
+	key := cacheKey(absDir, relPath)
+	info, err := os.Stat(path)
+	if err != nil {
+		return fail(err)
+	}
+	if r, ok := cache.lookup(key, info); ok {
+		res.entries, res.diagnostics, res.tsEntries = r.Entries, r.Diagnostics, r.TSEntries
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fail(err)
+	}
+	hash := contentHash(data)
+	if r, ok := cache.lookupHash(key, info, hash); ok {
+		res.entries, res.diagnostics, res.tsEntries = r.Entries, r.Diagnostics, r.TSEntries
+		return
+	}
+	r := extractor.Extract(relPath, data, cfg)
+	cache.store(key, info, hash, r)
+	res.entries, res.diagnostics, res.tsEntries = r.Entries, r.Diagnostics, r.TSEntries
+	return
+}
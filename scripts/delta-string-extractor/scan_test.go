@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.ts", "a.ts", true},
+		{"*.ts", "dir/a.ts", false},
+		{"**/*.ts", "a.ts", true},
+		{"**/*.ts", "dir/sub/a.ts", true},
+		{"routes/**", "routes/a/b.tsx", true},
+		{"routes/**", "components/a.tsx", false},
+		{"**/node_modules", "a/node_modules", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.name); got != c.want {
+			t.Errorf("matchGlob(%q, %q): wanted %v, got %v", c.pattern, c.name, c.want, got)
+		}
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":               "",
+		".gitignore":              "*.gen.ts\nbuild/\n",
+		"app/a.ts":                "",
+		"app/b.tsx":               "",
+		"app/c.js":                "",
+		"app/x.gen.ts":            "",
+		"app/build/d.ts":          "",
+		"app/node_modules/e.ts":   "",
+		"app/sub/.gitignore":      "!keep.gen.ts\n",
+		"app/sub/keep.gen.ts":     "",
+		"app/sub/f.test.ts":       "",
+		"app/emails/welcome.mdx":  "",
+		"app/emails/welcome.html": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appDir := filepath.Join(dir, "app")
+	list := func(filter fileFilter) []string {
+		t.Helper()
+		paths, err := listFiles(appDir, filter.withDefaults())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for _, p := range paths {
+			rel, _ := filepath.Rel(appDir, p)
+			res = append(res, filepath.ToSlash(rel))
+		}
+		return res
+	}
+
+	if want, got := []string{"a.ts", "b.tsx", "sub/f.test.ts", "sub/keep.gen.ts"}, list(fileFilter{}); !reflect.DeepEqual(want, got) {
+		t.Errorf("defaults: wanted %v, got %v", want, got)
+	}
+
+	noGitignore := false
+	want := []string{"a.ts", "b.tsx", "build/d.ts", "c.js", "emails/welcome.mdx", "sub/keep.gen.ts", "x.gen.ts"}
+	got := list(fileFilter{
+		Extensions: []string{".ts", ".tsx", ".js", ".mdx"},
+		Exclude:    []string{"**/node_modules", "**/*.test.ts"},
+		Gitignore:  &noGitignore,
+	})
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("extensions and exclude: wanted %v, got %v", want, got)
+	}
+
+	if want, got := []string{"emails/welcome.mdx"}, list(fileFilter{Extensions: []string{".mdx"}, Include: []string{"emails/**"}}); !reflect.DeepEqual(want, got) {
+		t.Errorf("include: wanted %v, got %v", want, got)
+	}
+}
+
+func TestFileCacheRoots(t *testing.T) {
+	dir := t.TempDir()
+	cacheFile := filepath.Join(dir, "cache", "cache.json")
+	roots := map[string]string{"app": "ctx.t({ code: \"a\", msg: \"A\" })", "tests": "ctx.t({ code: \"b\", msg: \"B\" })"}
+	for name, content := range roots {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, "a.ts"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := extractor.DefaultConfig()
+	// extract scans a root with its own cache, as a separate run would
+	extract := func(name string) *fileCache {
+		cache := loadFileCache(cacheFile, "test")
+		extractFiles(filepath.Join(dir, name), []string{filepath.Join(dir, name, "a.ts")}, cfg, cache, 1)
+		return cache
+	}
+
+	// two runs loaded the cache before either saved
+	app, tests := extract("app"), extract("tests")
+	for _, c := range []*fileCache{app, tests} {
+		if err := c.save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(loadFileCache(cacheFile, "test").Files); got != 2 {
+		t.Errorf("wanted the files of both roots, got %d", got)
+	}
+	if err := os.Remove(filepath.Join(dir, "app", "a.ts")); err != nil {
+		t.Fatal(err)
+	}
+	// the file of app was removed, the root is scanned without files
+	app = loadFileCache(cacheFile, "test")
+	extractFiles(filepath.Join(dir, "app"), nil, cfg, app, 1)
+	if err := app.save(); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key := range loadFileCache(cacheFile, "test").Files {
+		keys = append(keys, key)
+	}
+	if want := []string{cacheKey(filepath.Join(dir, "tests"), "a.ts")}; !reflect.DeepEqual(want, keys) {
+		t.Errorf("wanted only the removed file of app dropped, got %v", keys)
+	}
+	if names, _ := os.ReadDir(filepath.Dir(cacheFile)); len(names) != 1 {
+		t.Errorf("wanted no temporary files, got %v", names)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"delta-string-extractor/extractor"
+)
+
+// typesModule is the module declaring the TranslationReplacements interface that the types file augments.
+const typesModule = "~/utils/translator"
+
+// renderTypes returns TypeScript declarations adding every code and its placeholders to the TranslationReplacements interface of app/utils/translator.ts. With them Translator rejects unknown codes and calls without a required replacement. Placeholders can have any value, plural messages need a number, see replacementsType.
+func renderTypes(entries []extractor.Entry) []byte {
+	// entries are sorted by code, the first entry of a code is used, conflicting messages are reported by renderEntriesJSON
+	var codes []string
+	byCode := make(map[string]extractor.Entry)
+	countKeys := make(map[string][]string)
+	for _, e := range entries {
+		if _, ok := byCode[e.Code]; !ok {
+			codes = append(codes, e.Code)
+			byCode[e.Code] = e
+		}
+		for _, k := range e.CountKeys {
+			if !inArray(countKeys[e.Code], k) {
+				countKeys[e.Code] = append(countKeys[e.Code], k)
+			}
+		}
+	}
+	sort.Strings(codes)
+
+	var b strings.Builder
+	b.WriteString("// Generated by scripts/delta-string-extractor from the translation calls, do not edit.\n")
+	fmt.Fprintf(&b, "import %s;\n\n", jsString(typesModule))
+	fmt.Fprintf(&b, "declare module %s {\n", jsString(typesModule))
+	b.WriteString("\tinterface TranslationReplacements {\n")
+	for _, code := range codes {
+		fmt.Fprintf(&b, "\t\t%s: %s;\n", jsString(code), replacementsType(byCode[code], countKeys[code]))
+	}
+	b.WriteString("\t}\n}\n")
+	return []byte(b.String())
+}
+
+// replacementsType returns the type of the replacements of a call with the message of e. countKeys are the replacements that may hold the number of a plural message at its call sites.
+//
+// createTranslator picks the plural form with the first integer replacement, whatever its key, so one of countKeys has to be a number. Without call sites passing an object literal any placeholder may be the number, and without placeholders any key, translator.ts requires the replacements for that type.
+func replacementsType(e extractor.Entry, countKeys []string) string {
+	placeholders := messagePlaceholders(e)
+	if len(e.Msgs) == 0 {
+		return objectType(placeholders, "")
+	}
+	if len(countKeys) == 0 {
+		countKeys = placeholders
+	}
+	switch len(countKeys) {
+	case 0:
+		return "{ [count: string]: number }"
+	case 1:
+		return objectType(placeholders, countKeys[0])
+	}
+	options := make([]string, len(countKeys))
+	for i, k := range countKeys {
+		options[i] = objectType(nil, k)
+	}
+	if len(placeholders) == 0 {
+		return strings.Join(options, " | ")
+	}
+	return objectType(placeholders, "") + " & (" + strings.Join(options, " | ") + ")"
+}
+
+// objectType returns an object type with the placeholders, of any type, and count, a number, unless it is empty.
+func objectType(placeholders []string, count string) string {
+	var props []string
+	if count != "" {
+		props = append(props, propertyName(count)+": number")
+	}
+	for _, p := range placeholders {
+		if p != count {
+			props = append(props, propertyName(p)+": unknown")
+		}
+	}
+	if len(props) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(props, "; ") + " }"
+}
+
+// messagePlaceholders returns the placeholders of msg or of all plural forms, in the order of extractor.PluralCategories.
+func messagePlaceholders(e extractor.Entry) []string {
+	if len(e.Msgs) == 0 {
+		return extractor.Placeholders(e.Msg)
+	}
+	var res []string
+	for _, form := range extractor.PluralCategories {
+		for _, p := range extractor.Placeholders(e.Msgs[form]) {
+			if !inArray(res, p) {
+				res = append(res, p)
+			}
+		}
+	}
+	return res
+}
+
+var identifierRE = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// propertyName quotes placeholder names that are not identifiers.
+func propertyName(name string) string {
+	if identifierRE.MatchString(name) {
+		return name
+	}
+	return jsString(name)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestRenderTypes(t *testing.T) {
+	entries := []extractor.Entry{
+		{Code: "common.save", Msg: "Save"},
+		{Code: "common.save", Msg: "Save", Location: "b.tsx:1"},
+		{Code: "hazard.delete", Msg: "Delete {name} from {list-name}?"},
+		// the count is not the first placeholder
+		{Code: "session.expires", Msgs: map[string]string{"one": "{user}, {n} minute left", "other": "{user}, {n} minutes left"}, CountKeys: []string{"n"}},
+		// replacements that are not an object literal, any placeholder may be the count
+		{Code: "session.users", Msgs: map[string]string{"one": "{user} and {n} other", "other": "{user} and {n} others"}},
+		// no placeholders, the count is a replacement only used to pick the form
+		{Code: "items", Msgs: map[string]string{"one": "One item", "other": "Many items"}, CountKeys: []string{"count"}},
+		{Code: "items.any", Msgs: map[string]string{"one": "One item", "other": "Many items"}, CountKeys: []string{"count"}},
+		{Code: "items.any", Msgs: map[string]string{"one": "One item", "other": "Many items"}, CountKeys: []string{"n"}, Location: "b.tsx:1"},
+		{Code: "items.unknown", Msgs: map[string]string{"one": "One item", "other": "Many items"}},
+	}
+	sortEntries(entries)
+	want := `// Generated by scripts/delta-string-extractor from the translation calls, do not edit.
+import "~/utils/translator";
+
+declare module "~/utils/translator" {
+	interface TranslationReplacements {
+		"common.save": {};
+		"hazard.delete": { name: unknown; "list-name": unknown };
+		"items": { count: number };
+		"items.any": { count: number } | { n: number };
+		"items.unknown": { [count: string]: number };
+		"session.expires": { n: number; user: unknown };
+		"session.users": { user: unknown; n: unknown } & ({ user: number } | { n: number });
+	}
+}
+`
+	if got := string(renderTypes(entries)); got != want {
+		t.Errorf("wanted\n%s\ngot\n%s", want, got)
+	}
+}
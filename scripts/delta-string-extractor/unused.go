@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"delta-string-extractor/extractor"
+	"delta-string-extractor/locales"
+)
+
+// isTestFile reports whether the file only exists for tests, for example foo.test.ts or __tests__/foo.ts.
+func isTestFile(relPath string) bool {
+	base := filepath.Base(relPath)
+	if strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") {
+		return true
+	}
+	for _, part := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if part == "__tests__" {
+			return true
+		}
+	}
+	return false
+}
+
+// keyUsage records where codes are referenced from.
+type keyUsage struct {
+	app   map[string]bool
+	tests map[string][]string
+}
+
+func newKeyUsage() *keyUsage {
+	return &keyUsage{app: make(map[string]bool), tests: make(map[string][]string)}
+}
+
+func (u *keyUsage) add(e extractor.Entry, test bool) {
+	if test {
+		u.tests[e.Code] = append(u.tests[e.Code], e.Location)
+		return
+	}
+	u.app[e.Code] = true
+}
+
+// classify splits locale ids into ones no code references and ones only tests reference.
+func (u *keyUsage) classify(ids []string) (orphaned, testOnly []string) {
+	for _, id := range ids {
+		switch {
+		case u.app[id]:
+		case len(u.tests[id]) != 0:
+			testOnly = append(testOnly, id)
+		default:
+			orphaned = append(orphaned, id)
+		}
+	}
+	sort.Strings(orphaned)
+	sort.Strings(testOnly)
+	return
+}
+
+func runUnused(args []string) error {
+	fs := flag.NewFlagSet("unused", flag.ExitOnError)
+	ef := registerExtractFlags(fs)
+	localesDir := fs.String("locales-dir", filepath.FromSlash("../../locales/app"), "directory with <lang>.json locale files")
+	var testDirs CommaSeparated
+	fs.Var(&testDirs, "test-dirs", "Comma-separated directories that only contain tests (default ../../tests)")
+	prune := fs.Bool("prune", false, "remove unused ids from all locale files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(testDirs) == 0 {
+		testDirs = CommaSeparated{filepath.FromSlash("../../tests")}
+	}
+
+	cfg, err := ef.config()
+	if err != nil {
+		return err
+	}
+
+	usage := newKeyUsage()
+	// set when some codes could not be read, then the list of used codes is incomplete
+	unresolved := false
+	var diags []extractor.Diagnostic
+	roots := ef.roots(root{})
+	cache := loadFileCache(*ef.cacheFile, cacheFingerprint(cfg))
+	collect := func(r root, allTests bool) error {
+		results, err := extractRoot(r, cfg, cache, *ef.workers)
+		if err != nil {
+			return err
+		}
+		var rootDiags []extractor.Diagnostic
+		for _, r := range results {
+			rootDiags = append(rootDiags, r.diagnostics...)
+			for _, d := range r.diagnostics {
+				switch d.Rule {
+				case extractor.RuleNonLiteral, extractor.RuleInvalidLiteral, extractor.RuleReadError:
+					unresolved = true
+				}
+			}
+			for _, e := range r.entries {
+				usage.add(e, allTests || isTestFile(r.relPath))
+			}
+		}
+		diags = append(diags, rootDiagnostics(roots, r, rootDiags)...)
+		return nil
+	}
+	for _, r := range roots {
+		if err := collect(r, false); err != nil {
+			return err
+		}
+	}
+	for _, dir := range testDirs {
+		// test directories use the extensions of the roots, their include patterns are relative to the roots
+		r := root{dir: dir, filter: fileFilter{Extensions: roots[0].filter.Extensions}.withDefaults()}
+		if err := collect(r, true); err != nil {
+			return err
+		}
+	}
+	if err := cache.save(); err != nil {
+		fmt.Fprintln(logw, "failed to save cache", err)
+	}
+
+	if err := ef.writeDiagnostics(diagnosticsDir(roots), diags); err != nil {
+		return err
+	}
+
+	files, err := locales.LanguageFiles(*localesDir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no locale files found in %s", *localesDir)
+	}
+
+	total := 0
+	unusedByLang := make(map[string][]string)
+	for _, lang := range locales.Languages(files) {
+		entries, err := locales.ReadTranslations(files[lang])
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(entries))
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+		}
+		orphaned, testOnly := usage.classify(ids)
+		unusedByLang[lang] = orphaned
+		total += len(orphaned)
+
+		fmt.Fprintf(logw, "%s: %d unused ids, %d ids only used in tests\n", lang, len(orphaned), len(testOnly))
+		for _, id := range orphaned {
+			fmt.Fprintf(logw, "  unused %s\n", id)
+		}
+		for _, id := range testOnly {
+			fmt.Fprintf(logw, "  test only %s (%s)\n", id, strings.Join(usage.tests[id], ", "))
+		}
+	}
+
+	if !*prune || total == 0 {
+		return nil
+	}
+	if unresolved {
+		// codes that are not literals are unknown, so used ids could be removed
+		return fmt.Errorf("not pruning, some translation calls could not be extracted statically")
+	}
+	for _, lang := range locales.Languages(files) {
+		ids := make(map[string]bool)
+		for _, id := range unusedByLang[lang] {
+			ids[id] = true
+		}
+		removed, err := locales.RemoveIDs(files[lang], ids)
+		if err != nil {
+			return err
+		}
+		if removed != 0 {
+			fmt.Fprintf(logw, "Removed %d unused ids from %s\n", removed, files[lang])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestKeyUsageClassify(t *testing.T) {
+	u := newKeyUsage()
+	u.add(extractor.Entry{Code: "used", Location: "a.tsx:1"}, isTestFile("routes/a.tsx"))
+	u.add(extractor.Entry{Code: "used", Location: "a.test.ts:1"}, isTestFile("services/a.test.ts"))
+	u.add(extractor.Entry{Code: "test.only", Location: "b.spec.ts:2"}, isTestFile("b.spec.ts"))
+	u.add(extractor.Entry{Code: "test.dir", Location: "__tests__/c.ts:3"}, isTestFile("x/__tests__/c.ts"))
+
+	orphaned, testOnly := u.classify([]string{"used", "test.only", "gone", "test.dir", "also.gone"})
+	if want := []string{"also.gone", "gone"}; !reflect.DeepEqual(want, orphaned) {
+		t.Errorf("orphaned: wanted %v, got %v", want, orphaned)
+	}
+	if want := []string{"test.dir", "test.only"}; !reflect.DeepEqual(want, testOnly) {
+		t.Errorf("test only: wanted %v, got %v", want, testOnly)
+	}
+}
+
+func TestRunUnusedPrune(t *testing.T) {
+	dir := t.TempDir()
+	// en.json as written by the extractor, fr.json indented with spaces and without a trailing newline as in locales/content
+	files := map[string]string{
+		"app/routes/a.tsx":        `ctx.t({ code: "used", msg: "Used" })`,
+		"tests/a.test.ts":         `ctx.t({ code: "test.only", msg: "Test" })`,
+		"locales/app/en.json":     "[\n\t{\n\t\t\"id\": \"gone\",\n\t\t\"translation\": \"Gone\"\n\t},\n\t{\n\t\t\"id\": \"test.only\",\n\t\t\"translation\": \"Test\"\n\t},\n\t{\n\t\t\"id\": \"used\",\n\t\t\"translation\": {\n\t\t\t\"other\": \"Used <b>\",\n\t\t\t\"one\": \"Used\"\n\t\t}\n\t}\n]\n",
+		"locales/app/fr.json":     "[\n    {\n        \"id\": \"used\",\n        \"translation\": \"Utilis\u00e9\"\n    },\n    {\n        \"id\": \"gone\",\n        \"translation\": \"Parti\"\n    }\n]",
+		"locales/app/es.json":     "[\n\t{\n\t\t\"id\": \"used\",\n\t\t\"translation\": \"Usado\"\n\t}\n]\n",
+		"locales/app/notes.txt":   "not a locale file",
+		"locales/content/en.json": "[\n\t{\n\t\t\"id\": \"gone\",\n\t\t\"translation\": \"Gone\"\n\t}\n]\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var log bytes.Buffer
+	defer func(w io.Writer) { logw = w }(logw)
+	logw = &log
+
+	err := runUnused([]string{
+		"--dir", filepath.Join(dir, "app"),
+		"--locales-dir", filepath.Join(dir, "locales", "app"),
+		"--test-dirs", filepath.Join(dir, "tests"),
+		"--cache-file", "",
+		"--prune",
+	})
+	if err != nil {
+		t.Fatalf("%v\n%s", err, log.String())
+	}
+
+	// only the unused ids are removed, the other entries, the indentation and the trailing newline are kept
+	want := map[string]string{
+		"locales/app/en.json":     "[\n\t{\n\t\t\"id\": \"test.only\",\n\t\t\"translation\": \"Test\"\n\t},\n\t{\n\t\t\"id\": \"used\",\n\t\t\"translation\": {\n\t\t\t\"other\": \"Used <b>\",\n\t\t\t\"one\": \"Used\"\n\t\t}\n\t}\n]\n",
+		"locales/app/fr.json":     "[\n    {\n        \"id\": \"used\",\n        \"translation\": \"Utilis\u00e9\"\n    }\n]",
+		"locales/app/es.json":     files["locales/app/es.json"],
+		"locales/app/notes.txt":   files["locales/app/notes.txt"],
+		"locales/content/en.json": files["locales/content/en.json"],
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s: wanted\n%s\ngot\n%s", name, content, data)
+		}
+	}
+	names, err := os.ReadDir(filepath.Join(dir, "locales", "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 4 {
+		t.Errorf("wanted no temporary files, got %v", names)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"delta-string-extractor/extractor"
+)
+
+// usage is a call site of a code in the usage index.
+type usage struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Callee string `json:"callee"`
+	// Scope is the top-level declaration containing the call, see extractor.Entry
+	Scope string `json:"scope,omitempty"`
+	// Route is the URL path of the route module the call is in, see routePath
+	Route string `json:"route,omitempty"`
+}
+
+func writeUsageIndex(usageFile string, entries []extractor.Entry) error {
+	data, err := renderUsageIndex(entries)
+	if err != nil {
+		return err
+	}
+	return writeAtomically(usageFile, data)
+}
+
+// renderUsageIndex returns the usage index file, mapping every code to all of its call sites.
+func renderUsageIndex(entries []extractor.Entry) ([]byte, error) {
+	index := make(map[string][]usage)
+	for _, e := range entries {
+		file, line, col := e.Position()
+		index[e.Code] = append(index[e.Code], usage{
+			File:   filepath.ToSlash(file),
+			Line:   line,
+			Column: col,
+			Callee: e.Callee,
+			Scope:  e.Scope,
+			Route:  routePath(file),
+		})
+	}
+	for _, list := range index {
+		sort.Slice(list, func(i, j int) bool {
+			a, b := list[i], list[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Column < b.Column
+		})
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal usage index: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// routePath returns the URL path of a route module following the remix-flat-routes conventions used in app/routes, for example routes/$lang+/admin+/edit.$id.tsx is /:lang/admin/edit/:id. Files outside of routes/ return an empty string.
+func routePath(relPath string) string {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	if len(parts) < 2 || parts[0] != "routes" {
+		return ""
+	}
+	last := len(parts) - 1
+	parts[last] = strings.TrimSuffix(parts[last], filepath.Ext(parts[last]))
+
+	var segments []string
+	for i, part := range parts[1:] {
+		part = strings.TrimSuffix(part, "+")
+		for _, s := range strings.Split(part, ".") {
+			switch {
+			case s == "$":
+				segments = append(segments, "*")
+			case strings.HasPrefix(s, "$"):
+				segments = append(segments, ":"+s[1:])
+			case s == "_index" || s == "index" || (s == "route" || s == "_layout") && i+1 == last:
+				// index routes and layout files add no segment
+			case strings.HasPrefix(s, "_"):
+				// pathless layout
+			default:
+				segments = append(segments, strings.TrimSuffix(s, "_"))
+			}
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestRoutePath(t *testing.T) {
+	tests := map[string]string{
+		"routes/$lang+/admin+/country-accounts+/edit.$id.tsx":                   "/:lang/admin/country-accounts/edit/:id",
+		"routes/$lang+/disaster-record+/edit-sub.$disRecId+/losses+/_index.tsx": "/:lang/disaster-record/edit-sub/:disRecId/losses",
+		"routes/$lang+/_authenticated+/settings.tsx":                            "/:lang/settings",
+		"routes/$.tsx":                       "/*",
+		"routes/_index.tsx":                  "/",
+		"routes/sso+/azure-b2c.callback.tsx": "/sso/azure-b2c/callback",
+		"components/Dialog.tsx":              "",
+	}
+	for in, want := range tests {
+		if got := routePath(filepath.FromSlash(in)); got != want {
+			t.Errorf("routePath(%q): wanted %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestDescribeLocations(t *testing.T) {
+	locs := []string{"a.tsx:1", "b.tsx:2", "c.tsx:3"}
+	tests := map[int]string{
+		0: "a.tsx:1",
+		2: "a.tsx:1, b.tsx:2 and 1 more",
+		5: "a.tsx:1, b.tsx:2, c.tsx:3",
+	}
+	for n, want := range tests {
+		if got := describeLocations(locs, n); got != want {
+			t.Errorf("describeLocations(%d): wanted %q, got %q", n, want, got)
+		}
+	}
+}
+
+func TestRenderUsageIndex(t *testing.T) {
+	entries := []extractor.Entry{
+		{Location: "routes/$lang+/about+/_index.tsx:12", Column: 7, Code: "common.close", Callee: "ctx.t", Scope: "Screen"},
+		{Location: "components/Dialog.tsx:3", Column: 2, Code: "common.close", Callee: "ctx.t", Scope: "Dialog"},
+	}
+	data, err := renderUsageIndex(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "common.close": [
+    {
+      "file": "components/Dialog.tsx",
+      "line": 3,
+      "column": 2,
+      "callee": "ctx.t",
+      "scope": "Dialog"
+    },
+    {
+      "file": "routes/$lang+/about+/_index.tsx",
+      "line": 12,
+      "column": 7,
+      "callee": "ctx.t",
+      "scope": "Screen",
+      "route": "/:lang/about"
+    }
+  ]
+}
+`
+	if string(data) != want {
+		t.Errorf("wanted %s, got %s", want, data)
+	}
+}
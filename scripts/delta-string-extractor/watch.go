@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"delta-string-extractor/extractor"
+)
+
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+	result  fileResult
+}
+
+// watcher keeps the extraction results of all files of a root in memory and re-extracts only files whose mtime or size changed since the last poll.
+type watcher struct {
+	root    root
+	opts    outputOptions
+	cfg     extractor.Config
+	cache   *fileCache
+	workers int
+
+	files map[string]watchedFile
+	// order is the walk order of files, used to merge results deterministically
+	order     []string
+	codes     map[string]bool
+	conflicts map[string]bool
+	// nearDuplicates are the printed near duplicate diagnostics, so they are only reported when new
+	nearDuplicates map[string]bool
+	written        []byte
+	usageWritten   []byte
+	typesWritten   []byte
+}
+
+// watch polls the roots for changes and rewrites the output file of a root whenever its merged result changes. It runs until the process is stopped.
+func watch(roots []root, opts outputOptions, cfg extractor.Config, cacheFile string, workers int, interval time.Duration) error {
+	cache := loadFileCache(cacheFile, cacheFingerprint(cfg))
+	watchers := make([]*watcher, 0, len(roots))
+	for _, r := range roots {
+		w := &watcher{
+			root:    r,
+			opts:    opts,
+			cfg:     cfg,
+			cache:   cache,
+			workers: workers,
+			files:   make(map[string]watchedFile),
+		}
+		// Only rewrite the output if it differs from what is on disk already
+		if data, err := os.ReadFile(r.outputFile); err == nil {
+			w.written = data
+		}
+		fmt.Fprintf(logw, "Watching %s for changes, writing to %s\n", r.dir, r.outputFile)
+		watchers = append(watchers, w)
+	}
+	for {
+		for _, w := range watchers {
+			if err := w.poll(); err != nil {
+				return err
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// poll re-extracts changed files and rewrites the output file if the result changed.
+func (w *watcher) poll() error {
+	paths, err := listFiles(w.root.dir, w.root.filter)
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	stats := make(map[string]os.FileInfo, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			// removed between listing and stat, picked up on the next poll
+			continue
+		}
+		stats[path] = info
+		prev, ok := w.files[path]
+		if !ok || !prev.modTime.Equal(info.ModTime()) || prev.size != info.Size() {
+			changed = append(changed, path)
+		}
+	}
+	removed := len(w.files) != len(stats)
+	if len(changed) == 0 && !removed {
+		return nil
+	}
+
+	results := extractFiles(w.root.dir, changed, w.cfg, w.cache, w.workers)
+	next := make(map[string]watchedFile, len(stats))
+	for path := range stats {
+		next[path] = w.files[path]
+	}
+	for i, path := range changed {
+		r := results[i]
+		printDiagnostics(r.diagnostics)
+		info := stats[path]
+		next[path] = watchedFile{modTime: info.ModTime(), size: info.Size(), result: r}
+	}
+	w.files = next
+	w.order = paths
+	if err := w.cache.save(); err != nil {
+		fmt.Fprintln(logw, "failed to save cache", err)
+	}
+
+	return w.update()
+}
+
+// update merges all results, reports new keys and conflicts, and writes the output file if it changed.
+func (w *watcher) update() error {
+	var entries, tsEntries []extractor.Entry
+	for _, path := range w.order {
+		f, ok := w.files[path]
+		if !ok {
+			continue
+		}
+		entries = append(entries, f.result.entries...)
+		tsEntries = append(tsEntries, f.result.tsEntries...)
+	}
+	if w.opts.generateCodes != "" {
+		// codes of the output file written before are reused for the same messages, so they stay the same between rebuilds
+		if _, err := generateCodes(entries, w.root.outputFile, w.opts.generateCodes); err != nil {
+			return err
+		}
+	}
+	sortEntries(entries)
+
+	data, conflicts, err := renderOutput(entries, tsEntries, w.opts.forRoot(w.root))
+	if err != nil {
+		return err
+	}
+
+	codes := make(map[string]bool)
+	for _, e := range entries {
+		if !codes[e.Code] && w.codes != nil && !w.codes[e.Code] {
+			fmt.Fprintf(logw, "new key %s at %s\n", e.Code, e.Location)
+		}
+		codes[e.Code] = true
+	}
+	w.codes = codes
+
+	conflictCodes := make(map[string]bool)
+	for _, c := range conflicts {
+		if !w.conflicts[c.Code] {
+			printDiagnostics(c.diagnostics(w.cfg))
+		}
+		conflictCodes[c.Code] = true
+	}
+	w.conflicts = conflictCodes
+
+	nearDuplicates := make(map[string]bool)
+	for _, d := range extractor.CheckNearDuplicates(entries, w.cfg) {
+		if !w.nearDuplicates[d.Message] {
+			fmt.Fprintln(logw, d)
+		}
+		nearDuplicates[d.Message] = true
+	}
+	w.nearDuplicates = nearDuplicates
+
+	if w.root.usageFile != "" {
+		usageData, err := renderUsageIndex(entries)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(usageData, w.usageWritten) {
+			if err := writeAtomically(w.root.usageFile, usageData); err != nil {
+				return err
+			}
+			w.usageWritten = usageData
+		}
+	}
+	if w.root.typesFile != "" {
+		typesData := renderTypes(entries)
+		if !bytes.Equal(typesData, w.typesWritten) {
+			if err := writeAtomically(w.root.typesFile, typesData); err != nil {
+				return err
+			}
+			w.typesWritten = typesData
+		}
+	}
+
+	if bytes.Equal(data, w.written) {
+		return nil
+	}
+	if err := writeAtomically(w.root.outputFile, data); err != nil {
+		return err
+	}
+	w.written = data
+	fmt.Fprintf(logw, "%s wrote %s (%d keys)\n", time.Now().Format("15:04:05"), w.root.outputFile, len(codes))
+	return nil
+}
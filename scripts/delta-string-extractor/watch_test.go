@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestWatchGenerateCodes(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app", "routes", "hazard.tsx")
+	if err := os.MkdirAll(filepath.Dir(src), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte(`ctx.t({ msg: "Save" })`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+	defer func(w io.Writer) { logw = w }(logw)
+	logw = &log
+
+	r := root{dir: filepath.Join(dir, "app"), outputFile: filepath.Join(dir, "en.json"), filter: fileFilter{}.withDefaults()}
+	opts := outputOptions{generateCodes: generateCodesSlug}
+	cfg := extractor.DefaultConfig()
+	cfg.GenerateCodes = true
+	w := &watcher{root: r, opts: opts, cfg: cfg, cache: loadFileCache("", ""), workers: 1, files: make(map[string]watchedFile)}
+
+	// every rebuild writes the same file as a plain run
+	for i, content := range []string{`ctx.t({ msg: "Save" })`, `ctx.t({ msg: "Save" }); ctx.t({ msg: "Save draft" })`} {
+		if err := os.WriteFile(src, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.poll(); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(r.outputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := extractOutput(r, cfg, loadFileCache("", ""), 1, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(want.data, got) || strings.Contains(string(got), `"id": ""`) {
+			t.Errorf("rebuild %d: wanted\n%s\ngot\n%s", i, want.data, got)
+		}
+	}
+}
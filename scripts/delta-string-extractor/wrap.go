@@ -0,0 +1,203 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"delta-string-extractor/extractor"
+)
+
+// location selects hardcoded strings by file, line and column, 0 matches any line or column.
+type location struct {
+	file         string
+	line, column int
+}
+
+func parseLocation(s string) (location, error) {
+	parts := strings.Split(filepath.ToSlash(s), ":")
+	loc := location{file: parts[0]}
+	var err error
+	if len(parts) > 1 {
+		if loc.line, err = strconv.Atoi(parts[1]); err != nil {
+			return loc, fmt.Errorf("invalid location %q, use file, file:line or file:line:column", s)
+		}
+	}
+	if len(parts) > 2 {
+		if loc.column, err = strconv.Atoi(parts[2]); err != nil {
+			return loc, fmt.Errorf("invalid location %q, use file, file:line or file:line:column", s)
+		}
+	}
+	if len(parts) > 3 {
+		return loc, fmt.Errorf("invalid location %q, use file, file:line or file:line:column", s)
+	}
+	return loc, nil
+}
+
+func (l location) match(file string, line, column int) bool {
+	return l.file == file && (l.line == 0 || l.line == line) && (l.column == 0 || l.column == column)
+}
+
+var ctxIdent = regexp.MustCompile(`\bctx\b`)
+
+// runWrap replaces hardcoded strings, see extractor.HardcodedStrings, with translation calls and regenerates the output files.
+func runWrap(args []string) error {
+	fs := flag.NewFlagSet("wrap", flag.ExitOnError)
+	ef := registerExtractFlags(fs)
+	outputFile := fs.String("output-file", filepath.FromSlash("../../app/locales/app/en.json"), "output file path, its ids are not reused for other messages")
+	dryRun := fs.Bool("dry-run", false, "print the changes as a patch instead of writing them")
+	namespace := fs.String("namespace", "", "namespace of the generated codes, default the namespace most codes of the file use")
+	callee := fs.String("callee", "ctx.t", "translation function the strings are wrapped in")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: delta-string-extractor wrap [flags] [file[:line[:column]]...]")
+		fmt.Fprintln(fs.Output(), "Without locations all hardcoded strings are wrapped. Files are relative to the root.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var locations []location
+	for _, arg := range fs.Args() {
+		loc, err := parseLocation(arg)
+		if err != nil {
+			return err
+		}
+		locations = append(locations, loc)
+	}
+	if *dryRun {
+		// keep stdout for the patch
+		logw = os.Stderr
+	}
+
+	cfg, err := ef.config()
+	if err != nil {
+		return err
+	}
+	roots := ef.roots(root{outputFile: *outputFile})
+	cache := loadFileCache(*ef.cacheFile, cacheFingerprint(cfg))
+	wrapped, changedFiles := 0, 0
+	for _, r := range roots {
+		out, err := extractOutput(r, cfg, cache, *ef.workers, outputOptions{})
+		if err != nil {
+			return err
+		}
+		codes := newCodeGenerator()
+		codes.addEntries(out.entries)
+		if err := codes.addOutputFile(r.outputFile); err != nil {
+			return err
+		}
+
+		paths, err := listFiles(r.dir, r.filter)
+		if err != nil {
+			return err
+		}
+		changed := false
+		for _, p := range paths {
+			rel, err := filepath.Rel(r.dir, p)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			edits := wrapEdits(rel, data, cfg, locations, codes, *namespace, *callee, out.entries)
+			if len(edits) == 0 {
+				continue
+			}
+			wrapped += len(edits)
+			changedFiles++
+			changed = true
+			if strings.HasPrefix(*callee, "ctx.") && !ctxIdent.Match(data) {
+				fmt.Fprintf(logw, "%s: ctx is not defined, add one to the components using the wrapped strings\n", rel)
+			}
+			if *dryRun {
+				fmt.Print(unifiedDiff(path.Join(repoPath(r.dir), filepath.ToSlash(rel)), data, edits))
+				continue
+			}
+			if err := writeAtomically(p, applyEdits(data, edits)); err != nil {
+				return err
+			}
+		}
+		if !changed || *dryRun {
+			continue
+		}
+		out, err = extractOutput(r, cfg, cache, *ef.workers, outputOptions{})
+		if err != nil {
+			return err
+		}
+		if err := writeAtomically(r.outputFile, out.data); err != nil {
+			return err
+		}
+		fmt.Fprintln(logw, "Updated", r.outputFile)
+	}
+	if err := cache.save(); err != nil {
+		fmt.Fprintln(logw, "failed to save cache", err)
+	}
+	fmt.Fprintf(logw, "Wrapped %d strings in %d files\n", wrapped, changedFiles)
+	return nil
+}
+
+// wrapEdits returns the edits replacing the selected hardcoded strings of a file with translation calls. Without locations all of them are selected. JSX text around plain names, such as Hello {user.name}!, becomes one call with the names as replacements, text mixed with other expressions is reported and skipped.
+func wrapEdits(relPath string, data []byte, cfg extractor.Config, locations []location, codes *codeGenerator, namespace, callee string, entries []extractor.Entry) []edit {
+	strs := extractor.HardcodedStrings(relPath, data, cfg)
+	if len(strs) == 0 {
+		return nil
+	}
+	if namespace == "" {
+		namespace = fileNamespace(relPath, entries)
+	}
+	lines := extractor.NewLineIndex(data)
+	file := filepath.ToSlash(relPath)
+	var edits []edit
+	for _, s := range strs {
+		if len(locations) != 0 {
+			line, col := lines.Position(s.Pos)
+			selected := false
+			for _, l := range locations {
+				selected = selected || l.match(file, line, col)
+			}
+			if !selected {
+				continue
+			}
+		}
+		if s.Mixed {
+			line, col := lines.Position(s.Pos)
+			fmt.Fprintf(logw, "%s:%d:%d: skipped %q, the text is mixed with expressions that are not plain names, wrap it by hand\n", file, line, col, s.Text)
+			continue
+		}
+		msg := s.Text
+		if s.Attribute == "" || data[s.Pos] != '{' {
+			// JSX text and quoted attributes are not JS strings, they may contain character references
+			msg = html.UnescapeString(msg)
+		}
+		code := codes.code(namespace, slugify(msg), messageID(msg, ""))
+		edits = append(edits, edit{
+			pos:  s.Pos,
+			end:  s.End,
+			text: fmt.Sprintf("{%s({ code: %s, msg: %s }%s)}", callee, jsString(code), jsString(msg), replacementsArg(s.Replacements)),
+		})
+	}
+	return edits
+}
+
+// replacementsArg returns the replacements argument of a wrapped string, for example , { name: user.name }, or nothing without placeholders.
+func replacementsArg(repl []extractor.Replacement) string {
+	if len(repl) == 0 {
+		return ""
+	}
+	props := make([]string, len(repl))
+	for i, r := range repl {
+		props[i] = r.Name
+		if r.Expr != r.Name {
+			props[i] += ": " + r.Expr
+		}
+	}
+	return ", { " + strings.Join(props, ", ") + " }"
+}
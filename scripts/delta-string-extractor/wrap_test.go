@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestWrapEdits(t *testing.T) {
+	src := `export function Page({ ctx, user }) {
+	return (
+		<div title="Settings &amp; more">
+			<p>Hello {user.name}, welcome back!</p>
+			<p>Total {items.length > 1 ? "items" : "item"}</p>
+		</div>
+	);
+}
+`
+	var log bytes.Buffer
+	defer func(w io.Writer) { logw = w }(logw)
+	logw = &log
+
+	edits := wrapEdits("page.tsx", []byte(src), extractor.DefaultConfig(), nil, newCodeGenerator(), "home", "ctx.t", nil)
+	got := string(applyEdits([]byte(src), edits))
+	want := `export function Page({ ctx, user }) {
+	return (
+		<div title={ctx.t({ code: "home.settings_more", msg: "Settings & more" })}>
+			<p>{ctx.t({ code: "home.hello_name_welcome_back", msg: "Hello {name}, welcome back!" }, { name: user.name })}</p>
+			<p>Total {items.length > 1 ? "items" : "item"}</p>
+		</div>
+	);
+}
+`
+	if got != want {
+		t.Errorf("wanted\n%s\ngot\n%s", want, got)
+	}
+	if !strings.Contains(log.String(), `page.tsx:5:7: skipped "Total"`) {
+		t.Errorf("wanted the mixed text reported, got %q", log.String())
+	}
+}
@@ -3,10 +3,13 @@ package main
 import (
 	"delta-string-extractor/extractor"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"maps"
 	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 )
 
 // translationEqual checks if two entries have equivalent translations
@@ -22,7 +25,108 @@ func translationMsgEqual(a, b extractor.Entry) bool {
 	}
 }
 
-func writeEntriesJSON(outputFile string, entries []extractor.Entry) error {
+// conflicting reports whether entries of the same code have different messages or contexts.
+func conflicting(entries []extractor.Entry) bool {
+	if len(entries) < 2 {
+		return false
+	}
+	for _, e := range entries[1:] {
+		if !translationMsgEqual(e, entries[0]) || e.Context != entries[0].Context {
+			return true
+		}
+	}
+	return false
+}
+
+// conflict is a code used with different messages.
+type conflict struct {
+	Code    string
+	Entries []extractor.Entry
+}
+
+// describeMessage returns the message and context of an entry for conflict messages.
+func describeMessage(e extractor.Entry) string {
+	var res string
+	if e.Msg != "" {
+		res = fmt.Sprintf("%q", e.Msg)
+	} else {
+		res = fmt.Sprintf("%v", e.Msgs)
+	}
+	if e.Context != "" {
+		res += fmt.Sprintf(" (context %q)", e.Context)
+	}
+	return res
+}
+
+// diagnostics reports every entry of the conflict with the messages used elsewhere.
+func (c conflict) diagnostics(cfg extractor.Config) []extractor.Diagnostic {
+	sev := cfg.Severity(extractor.RuleConflictingMessage)
+	if sev == extractor.SeverityOff {
+		return nil
+	}
+	var res []extractor.Diagnostic
+	for i, e := range c.Entries {
+		var others []string
+		for j, other := range c.Entries {
+			if i != j && (!translationMsgEqual(e, other) || e.Context != other.Context) {
+				others = append(others, fmt.Sprintf("%s at %s", describeMessage(other), other.Location))
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+		file, line, col := e.Position()
+		res = append(res, extractor.Diagnostic{
+			Rule:     extractor.RuleConflictingMessage,
+			Severity: sev,
+			File:     file,
+			Line:     line,
+			Column:   col,
+			Message:  fmt.Sprintf("code %q has conflicting messages: %s here, %s", c.Code, describeMessage(e), strings.Join(others, ", ")),
+		})
+	}
+	return res
+}
+
+func conflictDiagnostics(conflicts []conflict, cfg extractor.Config) []extractor.Diagnostic {
+	var res []extractor.Diagnostic
+	for _, c := range conflicts {
+		res = append(res, c.diagnostics(cfg)...)
+	}
+	return res
+}
+
+// outputOptions control the content of the output file.
+type outputOptions struct {
+	// descLocations is the number of locations listed in descriptions, 0 for only the location of the entry used
+	descLocations int
+	// compat is the output format of another extractor to reproduce, see renderEntriesTS
+	compat string
+	// pathPrefix is prepended to file paths in compat output, see forRoot
+	pathPrefix string
+	// generateCodes is the --generate-codes mode for calls without a code, see generateCodes
+	generateCodes string
+}
+
+// forRoot returns the options for the output file of r. The TypeScript extractor runs in the repository root, so its paths start with the directory of the root.
+func (o outputOptions) forRoot(r root) outputOptions {
+	if o.compat == compatTS {
+		o.pathPrefix = repoPath(r.dir)
+	}
+	return o
+}
+
+// renderOutput returns the content of the output file and the conflicting codes of entries. With --compat=ts the file is rendered from tsEntries, the calls as scripts/extractor-i18n.ts reads them, see extractor.ExtractTS.
+func renderOutput(entries, tsEntries []extractor.Entry, opts outputOptions) (data []byte, conflicts []conflict, err error) {
+	data, conflicts, err = renderEntriesJSON(entries, opts)
+	if err != nil || opts.compat != compatTS {
+		return data, conflicts, err
+	}
+	return renderEntriesTS(tsEntries, opts.pathPrefix), conflicts, nil
+}
+
+// renderEntriesJSON merges entries by code and returns the content of the output file, along with the codes that have conflicting translations.
+func renderEntriesJSON(entries []extractor.Entry, opts outputOptions) (data []byte, conflicts []conflict, err error) {
 	type entryGroup struct {
 		entries []extractor.Entry
 	}
@@ -39,6 +143,10 @@ func writeEntriesJSON(outputFile string, entries []extractor.Entry) error {
 	}
 
 	bestEntry := make(map[string]extractor.Entry)
+	// descs are the descriptions of all entries of a code, without duplicates
+	descs := make(map[string]string)
+	// locations are the locations of all entries of a code, starting with the one of the best entry
+	locations := make(map[string][]string)
 
 	{
 		// Extract and sort keys for stable iteration
@@ -59,27 +167,33 @@ func writeEntriesJSON(outputFile string, entries []extractor.Entry) error {
 			first = group.entries[0]
 
 			for _, e := range group.entries[1:] {
-				// Check if translations differ
-				if !translationMsgEqual(e, first) {
-					// Log all entries in the group – conflict detected
-					fmt.Printf("conflicting translations for key %q:\n", key)
-					for _, entry := range group.entries {
-						if entry.Msg != "" {
-							fmt.Printf("  - %s: %q\n", entry.Location, entry.Msg)
-						}
-						if len(entry.Msgs) != 0 {
-							fmt.Printf("  - %s: %v\n", entry.Location, entry.Msgs)
-						}
-					}
-					break // Log once, not for every mismatch
+				// Check if translations or contexts differ
+				if !translationMsgEqual(e, first) || e.Context != first.Context {
+					// Record all entries in the group – conflict detected
+					conflicts = append(conflicts, conflict{Code: key, Entries: group.entries})
+					break // Record once, not for every mismatch
 				}
 
 				// Prefer entry with description
-				if e.Desc != "" && best.Desc == "" {
+				if e.Description() != "" && best.Description() == "" {
 					best = e
 				}
 			}
 			bestEntry[key] = best
+
+			var all []string
+			for _, e := range group.entries {
+				all = append(all, e.Description())
+			}
+			descs[key] = extractor.JoinDesc(all...)
+
+			locs := []string{best.Location}
+			for _, e := range group.entries {
+				if !inArray(locs, e.Location) {
+					locs = append(locs, e.Location)
+				}
+			}
+			locations[key] = locs
 		}
 	}
 
@@ -96,12 +210,15 @@ func writeEntriesJSON(outputFile string, entries []extractor.Entry) error {
 		for _, k := range keys {
 			e := bestEntry[k]
 
-			// Build description
-			desc := e.Desc
+			// Build description, Weblate shows it to translators so the context is included
+			desc := descs[k]
+			if e.Context != "" {
+				desc = extractor.JoinDesc("Context: "+e.Context+".", desc)
+			}
 			if desc != "" {
 				desc += " "
 			}
-			desc += "File: " + e.Location
+			desc += "File: " + describeLocations(locations[k], opts.descLocations)
 
 			// Build translation
 			var translation any
@@ -118,24 +235,142 @@ func writeEntriesJSON(outputFile string, entries []extractor.Entry) error {
 				"description": desc,
 				"translation": translation,
 			}
+			if e.Context != "" {
+				entry["context"] = e.Context
+			}
 
 			out = append(out, entry)
 		}
 	}
 
 	// Marshal to pretty JSON
-	data, err := json.MarshalIndent(out, "", "  ")
+	data, err = json.MarshalIndent(out, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
+		return nil, nil, fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
-	return writeAtomically(outputFile, data)
+	return data, conflicts, nil
+}
+
+// describeLocations lists the first n locations, followed by the number of other ones.
+func describeLocations(locs []string, n int) string {
+	if n <= 1 || len(locs) == 1 {
+		return locs[0]
+	}
+	if len(locs) <= n {
+		return strings.Join(locs, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(locs[:n], ", "), len(locs)-n)
 }
 
 func writeAtomically(filename string, data []byte) error {
-	tempFile := filename + ".tmp"
-	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+	// output files of new roots may be in directories that do not exist yet
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	// the temporary file has a random name, so concurrent runs writing the same file do not clobber each other
+	tempFile, err := writeTemp(filename, ".tmp", data)
+	if err != nil {
 		return err
 	}
-	return os.Rename(tempFile, filename)
+	if err := os.Rename(tempFile, filename); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+	return nil
+}
+
+// rename is os.Rename, tests replace it to make it fail.
+var rename = os.Rename
+
+// writeFilesAtomically writes all files or none of them if writing fails: the data is written to temporary files first, which replace the files once all of them were written. Existing files are backed up first, if a file can not be replaced the files replaced before are restored. No temporary files are left behind.
+func writeFilesAtomically(files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// temps are the new data and backups the old data of names, backups are empty for new files
+	temps := make([]string, len(names))
+	backups := make([]string, len(names))
+	defer func() {
+		for i := range names {
+			removeTemp(temps[i])
+			removeTemp(backups[i])
+		}
+	}()
+	for i, name := range names {
+		temp, err := writeTemp(name, ".tmp", files[name])
+		if err != nil {
+			return err
+		}
+		temps[i] = temp
+		old, err := os.ReadFile(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if backups[i], err = writeTemp(name, ".bak", old); err != nil {
+			return err
+		}
+	}
+
+	for i, name := range names {
+		if err := rename(temps[i], name); err != nil {
+			if rerr := restoreFiles(names[:i], backups[:i]); rerr != nil {
+				return fmt.Errorf("%w, restoring the files written before failed: %v", err, rerr)
+			}
+			return err
+		}
+		temps[i] = ""
+	}
+	return nil
+}
+
+// restoreFiles restores names from their backups and removes the ones that did not exist before. The backups are moved back.
+func restoreFiles(names, backups []string) error {
+	var errs []error
+	for i, name := range names {
+		var err error
+		if backups[i] == "" {
+			err = os.Remove(name)
+		} else if err = os.Rename(backups[i], name); err == nil {
+			backups[i] = ""
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeTemp writes data to a new temporary file in the directory of filename, named after it with a random part and suffix, and returns its path.
+func writeTemp(filename, suffix string, data []byte) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*"+suffix)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		// CreateTemp creates files only readable by the owner
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// removeTemp removes a temporary file, if there is one.
+func removeTemp(name string) {
+	if name != "" {
+		os.Remove(name)
+	}
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"delta-string-extractor/extractor"
+)
+
+func TestRenderEntriesJSONDescriptions(t *testing.T) {
+	entries := []extractor.Entry{
+		{Location: "a.tsx:1", Code: "common.close", Msg: "Close"},
+		{Location: "b.tsx:2", Code: "common.close", Msg: "Close", Desc: "Closes the dialog."},
+		{Location: "c.tsx:3", Code: "common.close", Msg: "Close", Desc: "Closes the form."},
+		{Location: "d.tsx:4", Code: "common.close", Msg: "Close", Desc: "Closes the dialog."},
+	}
+	data, conflicts, err := renderEntriesJSON(entries, outputOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("wanted no conflicts, got %v", conflicts)
+	}
+	got, err := parseFileEntries(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Closes the dialog. Closes the form. File: b.tsx:2"
+	if got["common.close"].Description != want {
+		t.Errorf("wanted %q, got %q", want, got["common.close"].Description)
+	}
+}
+
+func TestRenderEntriesJSONContext(t *testing.T) {
+	entries := []extractor.Entry{
+		{Location: "a.tsx:1", Code: "common.close", Msg: "Close", Context: "verb", Desc: "Button."},
+		{Location: "b.tsx:2", Code: "common.close", Msg: "Close", Context: "verb"},
+		{Location: "c.tsx:3", Code: "common.near", Msg: "Close", Context: "adjective"},
+		{Location: "d.tsx:4", Code: "common.near", Msg: "Close"},
+	}
+	data, conflicts, err := renderEntriesJSON(entries, outputOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Code != "common.near" {
+		t.Errorf("wanted a conflict for common.near, got %v", conflicts)
+	}
+	got, err := parseFileEntries(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fileEntry{ID: "common.close", Description: "Context: verb. Button. File: a.tsx:1", Context: "verb", Translation: "Close"}
+	if got["common.close"] != want {
+		t.Errorf("wanted %+v, got %+v", want, got["common.close"])
+	}
+}
+
+func TestWriteFilesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	a, b, c := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), filepath.Join(dir, "c.json")
+	for _, f := range []string{a, c} {
+		if err := os.WriteFile(f, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string][]byte{a: []byte("new a"), b: []byte("new b"), c: []byte("new c")}
+	// list returns the files of dir and their content
+	list := func() map[string]string {
+		res := make(map[string]string)
+		names, _ := os.ReadDir(dir)
+		for _, n := range names {
+			data, _ := os.ReadFile(filepath.Join(dir, n.Name()))
+			res[n.Name()] = string(data)
+		}
+		return res
+	}
+
+	// replacing c fails after a and b were written
+	defer func() { rename = os.Rename }()
+	rename = func(from, to string) error {
+		if to == c {
+			return errors.New("rename failed")
+		}
+		return os.Rename(from, to)
+	}
+	if err := writeFilesAtomically(files); err == nil {
+		t.Fatal("wanted an error")
+	}
+	if got, want := list(), map[string]string{"a.json": "old", "c.json": "old"}; !maps.Equal(got, want) {
+		t.Errorf("wanted the old files %v without temporary files, got %v", want, got)
+	}
+
+	rename = os.Rename
+	if err := writeFilesAtomically(files); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := list(), map[string]string{"a.json": "new a", "b.json": "new b", "c.json": "new c"}; !maps.Equal(got, want) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}